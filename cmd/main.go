@@ -1,12 +1,19 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/agl/fio/internal/application/services"
+	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/interfaces"
 	"github.com/agl/fio/internal/infrastructure/repositories"
 	"github.com/agl/fio/internal/presentation/controllers"
+	"github.com/agl/fio/internal/presentation/extractors"
 	. "github.com/agl/fio/pkg/logger"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
@@ -69,8 +76,130 @@ func main() {
 	Log.Info("Successfully connected to db")
 
 	repo := repositories.NewPersonRepository(db)
+
+	backfilled, err := repo.BackfillLatinKeys(context.Background(), 500)
+	if err != nil {
+		Log.Info("Could not backfill latin name keys", "err", err)
+
+		return
+	}
+	if backfilled > 0 {
+		Log.Info("Latin name keys backfilled", "count", backfilled)
+	}
+
 	service := services.NewPersonService(repo)
-	handler := controllers.NewPersonHandler(service)
+	enricher, reenricher, cache, breakers, err := newEnricher(db)
+
+	if err != nil {
+		Log.Info("Could not set up enrichment", "err", err)
+
+		return
+	}
+
+	jobs := newJobRunner(repo, enricher, reenricher)
+	go jobs.Start(context.Background())
+
+	handler := controllers.NewPersonHandler(service, enricher, controllers.NewAdminHandler(cache, breakers, jobs))
 
 	handler.StartApi()
 }
+
+// newJobRunner schedules the background enrichment jobs. The re-enrichment
+// job only runs when ENRICHMENT_REENRICH_INTERVAL is set and queries the
+// providers through reenricher, past the cache.
+func newJobRunner(repo interfaces.PersonRepository, enricher, reenricher interfaces.Enricher) *services.JobRunner {
+	jobs := services.NewJobRunner()
+
+	workerInterval, _ := time.ParseDuration(os.Getenv("ENRICHMENT_WORKER_INTERVAL"))
+	if workerInterval <= 0 {
+		workerInterval = services.DefaultWorkerInterval
+	}
+	workerMaxAttempts, _ := strconv.Atoi(os.Getenv("ENRICHMENT_WORKER_MAX_ATTEMPTS"))
+	jobs.Schedule(services.NewPendingEnrichmentJob(repo, enricher, services.DefaultWorkerBatchSize, workerMaxAttempts), workerInterval)
+
+	reenrichInterval, _ := time.ParseDuration(os.Getenv("ENRICHMENT_REENRICH_INTERVAL"))
+	if reenrichInterval > 0 {
+		maxAge, _ := time.ParseDuration(os.Getenv("ENRICHMENT_REENRICH_MAX_AGE"))
+		minConfidence, _ := strconv.ParseFloat(os.Getenv("ENRICHMENT_REENRICH_MIN_CONFIDENCE"), 64)
+		jobs.Schedule(services.NewReenrichmentJob(repo, reenricher, services.ReenrichmentOptions{
+			MaxAge:        maxAge,
+			MinConfidence: minConfidence,
+		}), reenrichInterval)
+	}
+
+	return jobs
+}
+
+// newEnricher builds the enrichment chain. ENRICHMENT_PROVIDER selects the
+// sources: "http" (default) for the public APIs, "offline" for the local
+// dataset only, "http+offline" for the APIs with the dataset as fallback.
+// The second chain shares the providers and breakers but bypasses the cache,
+// refreshing it with what it fetches.
+func newEnricher(db *sql.DB) (*extractors.Chain, *extractors.Chain, *extractors.NameCache, extractors.Breakers, error) {
+	var store interfaces.EnrichmentCacheStore
+	if os.Getenv("ENRICHMENT_CACHE_POSTGRES") == "true" {
+		store = repositories.NewEnrichmentCacheRepository(db)
+	}
+
+	cacheSize, _ := strconv.Atoi(os.Getenv("ENRICHMENT_CACHE_SIZE"))
+	cacheTTL, _ := time.ParseDuration(os.Getenv("ENRICHMENT_CACHE_TTL"))
+	cache := extractors.NewNameCache(cacheSize, cacheTTL, store)
+
+	retry := extractors.DefaultRetryPolicy
+	if attempts, err := strconv.Atoi(os.Getenv("ENRICHMENT_RETRY_ATTEMPTS")); err == nil {
+		retry.Attempts = attempts
+	}
+
+	breakerThreshold, _ := strconv.Atoi(os.Getenv("ENRICHMENT_BREAKER_THRESHOLD"))
+	breakerCooldown, _ := time.ParseDuration(os.Getenv("ENRICHMENT_BREAKER_COOLDOWN"))
+
+	mode := os.Getenv("ENRICHMENT_PROVIDER")
+
+	var dataset *extractors.OfflineDataset
+	if mode == "offline" || mode == "http+offline" {
+		var err error
+		dataset, err = extractors.LoadOfflineDataset(os.Getenv("ENRICHMENT_DATASET"))
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+
+	var breakers extractors.Breakers
+	var providers, fresh []interfaces.Provider
+	if mode == "offline" {
+		providers = dataset.Providers()
+		fresh = dataset.Providers()
+	} else {
+		providers = extractors.ProvidersFromEnv(http.DefaultClient, retry)
+		fresh = make([]interfaces.Provider, len(providers))
+		for i, provider := range providers {
+			breaker := extractors.NewBreakerProvider(provider, breakerThreshold, breakerCooldown)
+			breakers = append(breakers, breaker)
+			cached := extractors.NewCachedProvider(breaker, cache)
+			providers[i], fresh[i] = cached, cached.Refreshing()
+
+			if dataset != nil {
+				providers[i] = extractors.NewFallbackProvider(providers[i], dataset.Provider(provider.Field()))
+				fresh[i] = extractors.NewFallbackProvider(fresh[i], dataset.Provider(provider.Field()))
+			}
+		}
+	}
+
+	if os.Getenv("ENRICHMENT_GENDER_RULES") == "true" {
+		for _, list := range [][]interfaces.Provider{providers, fresh} {
+			for i, provider := range list {
+				if provider.Field() == entities.FieldGender {
+					list[i] = extractors.NewSlavicGenderProvider(provider)
+				}
+			}
+		}
+	}
+
+	providerTimeout, _ := time.ParseDuration(os.Getenv("ENRICHMENT_TIMEOUT"))
+	options := extractors.ChainOptions{
+		Timeout:         providerTimeout,
+		NationalityHint: os.Getenv("ENRICHMENT_COUNTRY_HINT") == "nationality",
+	}
+
+	return extractors.NewChain(options, providers...), extractors.NewChain(options, fresh...), cache, breakers, nil
+}
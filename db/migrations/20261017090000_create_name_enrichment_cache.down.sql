@@ -0,0 +1 @@
+DROP TABLE IF EXISTS name_enrichment_cache;
\ No newline at end of file
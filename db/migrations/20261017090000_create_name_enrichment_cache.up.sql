@@ -0,0 +1,9 @@
+CREATE TABLE name_enrichment_cache (
+    name VARCHAR(100) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (name, provider)
+);
+
+CREATE INDEX idx_name_enrichment_cache_expires_at ON name_enrichment_cache(expires_at);
@@ -0,0 +1,20 @@
+DO $$
+DECLARE
+    incomplete BIGINT;
+BEGIN
+    SELECT count(*) INTO incomplete FROM people WHERE age IS NULL OR gender_id IS NULL OR nationality_id IS NULL;
+
+    IF incomplete > 0 THEN
+        RAISE EXCEPTION '% people have no age, gender or nationality, which the previous schema requires', incomplete
+            USING HINT = 'Fill in or delete these people before migrating down';
+    END IF;
+END $$;
+
+DROP INDEX IF EXISTS idx_people_pending_fields;
+
+ALTER TABLE people DROP COLUMN IF EXISTS enrichment_attempts;
+ALTER TABLE people DROP COLUMN IF EXISTS pending_fields;
+
+ALTER TABLE people ALTER COLUMN nationality_id SET NOT NULL;
+ALTER TABLE people ALTER COLUMN gender_id SET NOT NULL;
+ALTER TABLE people ALTER COLUMN age SET NOT NULL;
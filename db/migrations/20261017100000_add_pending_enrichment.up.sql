@@ -0,0 +1,8 @@
+ALTER TABLE people ALTER COLUMN age DROP NOT NULL;
+ALTER TABLE people ALTER COLUMN gender_id DROP NOT NULL;
+ALTER TABLE people ALTER COLUMN nationality_id DROP NOT NULL;
+
+ALTER TABLE people ADD COLUMN pending_fields TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE people ADD COLUMN enrichment_attempts INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_people_pending_fields ON people(id) WHERE cardinality(pending_fields) > 0;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS person_enrichment;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE person_enrichment (
+    person_id INTEGER NOT NULL REFERENCES people(id) ON DELETE CASCADE,
+    field VARCHAR(20) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    probability DOUBLE PRECISION,
+    sample_count INTEGER,
+    candidates JSONB NOT NULL DEFAULT '[]',
+    enriched_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (person_id, field)
+);
+
+CREATE INDEX idx_person_enrichment_probability ON person_enrichment(probability);
@@ -0,0 +1 @@
+ALTER TABLE person_enrichment DROP COLUMN IF EXISTS country_hint;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE person_enrichment ADD COLUMN country_hint VARCHAR(2);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE person_enrichment DROP COLUMN IF EXISTS rule;
\ No newline at end of file
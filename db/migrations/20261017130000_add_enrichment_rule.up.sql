@@ -0,0 +1 @@
+ALTER TABLE person_enrichment ADD COLUMN rule VARCHAR(50);
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS enrichment_audit;
+
+DROP INDEX IF EXISTS idx_person_enrichment_enriched_at;
+
+ALTER TABLE people DROP COLUMN IF EXISTS enrichment_checked_at;
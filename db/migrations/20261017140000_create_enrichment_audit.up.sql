@@ -0,0 +1,16 @@
+ALTER TABLE people ADD COLUMN enrichment_checked_at TIMESTAMPTZ;
+
+CREATE INDEX idx_person_enrichment_enriched_at ON person_enrichment(enriched_at);
+
+CREATE TABLE enrichment_audit (
+    id SERIAL PRIMARY KEY,
+    person_id INTEGER NOT NULL REFERENCES people(id) ON DELETE CASCADE,
+    field VARCHAR(20) NOT NULL,
+    old_value TEXT,
+    new_value TEXT NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    job VARCHAR(50) NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_enrichment_audit_person_id ON enrichment_audit(person_id);
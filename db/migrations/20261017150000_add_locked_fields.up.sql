@@ -0,0 +1 @@
+ALTER TABLE people ADD COLUMN locked_fields TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_people_patronymic_key;
+DROP INDEX IF EXISTS idx_people_surname_key;
+DROP INDEX IF EXISTS idx_people_name_key;
+
+ALTER TABLE people DROP COLUMN IF EXISTS patronymic_key;
+ALTER TABLE people DROP COLUMN IF EXISTS surname_key;
+ALTER TABLE people DROP COLUMN IF EXISTS name_key;
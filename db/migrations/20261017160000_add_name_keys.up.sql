@@ -0,0 +1,20 @@
+UPDATE people SET
+    name = regexp_replace(btrim(normalize(name, NFC)), '\s+', ' ', 'g'),
+    surname = regexp_replace(btrim(normalize(surname, NFC)), '\s+', ' ', 'g'),
+    patronymic = NULLIF(regexp_replace(btrim(normalize(patronymic, NFC)), '\s+', ' ', 'g'), '');
+
+ALTER TABLE people ADD COLUMN name_key VARCHAR(100);
+ALTER TABLE people ADD COLUMN surname_key VARCHAR(100);
+ALTER TABLE people ADD COLUMN patronymic_key VARCHAR(100);
+
+UPDATE people SET
+    name_key = replace(lower(name), 'ё', 'е'),
+    surname_key = replace(lower(surname), 'ё', 'е'),
+    patronymic_key = replace(lower(patronymic), 'ё', 'е');
+
+ALTER TABLE people ALTER COLUMN name_key SET NOT NULL;
+ALTER TABLE people ALTER COLUMN surname_key SET NOT NULL;
+
+CREATE INDEX idx_people_name_key ON people(name_key);
+CREATE INDEX idx_people_surname_key ON people(surname_key);
+CREATE INDEX idx_people_patronymic_key ON people(patronymic_key);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_people_patronymic_latin;
+DROP INDEX IF EXISTS idx_people_surname_latin;
+DROP INDEX IF EXISTS idx_people_name_latin;
+DROP INDEX IF EXISTS idx_people_latin_keys_pending;
+
+ALTER TABLE people DROP COLUMN IF EXISTS latin_keys_pending;
+ALTER TABLE people DROP COLUMN IF EXISTS patronymic_latin;
+ALTER TABLE people DROP COLUMN IF EXISTS surname_latin;
+ALTER TABLE people DROP COLUMN IF EXISTS name_latin;
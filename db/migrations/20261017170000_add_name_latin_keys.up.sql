@@ -0,0 +1,12 @@
+ALTER TABLE people ADD COLUMN name_latin TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE people ADD COLUMN surname_latin TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE people ADD COLUMN patronymic_latin TEXT[] NOT NULL DEFAULT '{}';
+
+-- existing rows are transliterated by the service on startup
+ALTER TABLE people ADD COLUMN latin_keys_pending BOOLEAN NOT NULL DEFAULT false;
+UPDATE people SET latin_keys_pending = true;
+CREATE INDEX idx_people_latin_keys_pending ON people(id) WHERE latin_keys_pending;
+
+CREATE INDEX idx_people_name_latin ON people USING GIN (name_latin);
+CREATE INDEX idx_people_surname_latin ON people USING GIN (surname_latin);
+CREATE INDEX idx_people_patronymic_latin ON people USING GIN (patronymic_latin);
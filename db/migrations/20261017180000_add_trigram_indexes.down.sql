@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_people_patronymic_key_trgm;
+DROP INDEX IF EXISTS idx_people_surname_key_trgm;
+DROP INDEX IF EXISTS idx_people_name_key_trgm;
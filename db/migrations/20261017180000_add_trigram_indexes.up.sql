@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_people_name_key_trgm ON people USING GIN (name_key gin_trgm_ops);
+CREATE INDEX idx_people_surname_key_trgm ON people USING GIN (surname_key gin_trgm_ops);
+CREATE INDEX idx_people_patronymic_key_trgm ON people USING GIN (patronymic_key gin_trgm_ops);
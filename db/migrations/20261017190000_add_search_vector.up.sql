@@ -0,0 +1,10 @@
+ALTER TABLE people ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian'::regconfig, coalesce(surname, '')), 'A') ||
+    setweight(to_tsvector('russian'::regconfig, coalesce(name, '')), 'B') ||
+    setweight(to_tsvector('russian'::regconfig, coalesce(patronymic, '')), 'C') ||
+    setweight(to_tsvector('simple'::regconfig, coalesce(surname, '')), 'A') ||
+    setweight(to_tsvector('simple'::regconfig, coalesce(name, '')), 'B') ||
+    setweight(to_tsvector('simple'::regconfig, coalesce(patronymic, '')), 'C')
+) STORED;
+
+CREATE INDEX idx_people_search_vector ON people USING GIN (search_vector);
@@ -0,0 +1,10 @@
+CREATE INDEX IF NOT EXISTS idx_people_age ON people(age);
+CREATE INDEX IF NOT EXISTS idx_people_patronymic_key ON people(patronymic_key);
+CREATE INDEX IF NOT EXISTS idx_people_surname_key ON people(surname_key);
+CREATE INDEX IF NOT EXISTS idx_people_name_key ON people(name_key);
+
+DROP INDEX IF EXISTS idx_people_age_id;
+DROP INDEX IF EXISTS idx_people_patronymic_key_id;
+DROP INDEX IF EXISTS idx_people_surname_key_name_key_id;
+DROP INDEX IF EXISTS idx_people_surname_key_id;
+DROP INDEX IF EXISTS idx_people_name_key_id;
@@ -0,0 +1,10 @@
+CREATE INDEX idx_people_name_key_id ON people(name_key, id);
+CREATE INDEX idx_people_surname_key_id ON people(surname_key, id);
+CREATE INDEX idx_people_surname_key_name_key_id ON people(surname_key, name_key, id);
+CREATE INDEX idx_people_patronymic_key_id ON people(patronymic_key, id);
+CREATE INDEX idx_people_age_id ON people(age, id);
+
+DROP INDEX IF EXISTS idx_people_name_key;
+DROP INDEX IF EXISTS idx_people_surname_key;
+DROP INDEX IF EXISTS idx_people_patronymic_key;
+DROP INDEX IF EXISTS idx_people_age;
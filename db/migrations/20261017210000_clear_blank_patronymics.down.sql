@@ -0,0 +1 @@
+-- a blank patronymic and a missing one are the same, nothing to restore
@@ -0,0 +1 @@
+UPDATE people SET patronymic = NULL WHERE patronymic = '';
@@ -15,14 +15,165 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/enrichment-breakers": {
+            "get": {
+                "description": "Get the circuit breaker state of every enrichment provider",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get enrichment circuit breakers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/extractors.BreakerStatus"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/enrichment-cache": {
+            "get": {
+                "description": "Get hit/miss counters and the number of cached entries",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get enrichment cache statistics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/extractors.CacheStats"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/enrichment-cache/{name}": {
+            "delete": {
+                "description": "Remove the cached results of every provider for the given first name",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Purge cached enrichment for a name",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/jobs": {
+            "get": {
+                "description": "Get the schedule, state and progress of the current or last run of every background job",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get background jobs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entities.JobStatus"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/jobs/{name}/run": {
+            "post": {
+                "description": "Start a run of the job without waiting for its schedule",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Run a background job now",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
         "/person": {
             "post": {
-                "description": "Create a person entity and enrich it with age, gender, and nationality by name",
+                "description": "Create a person entity and enrich it with age, gender, and nationality by name.\nWith ENRICHMENT_POLICY=best-effort fields that could not be enriched are listed in \"pending\" and filled in later.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
                     "People"
@@ -30,12 +181,12 @@ const docTemplate = `{
                 "summary": "Create a new person",
                 "parameters": [
                     {
-                        "description": "Person object",
+                        "description": "Person object, country_id optionally localizes age and gender prediction",
                         "name": "person",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/entities.Person"
+                            "$ref": "#/definitions/entities.ReceivedPerson"
                         }
                     }
                 ],
@@ -52,18 +203,36 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     },
                     "409": {
                         "description": "Conflict",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "Seconds until the enrichment quota is renewed"
                             }
                         }
                     }
@@ -72,9 +241,10 @@ const docTemplate = `{
         },
         "/person/filter": {
             "get": {
-                "description": "Get a list of people filtered by parameters with pagination",
+                "description": "Get a list of people filtered by parameters. Pages are read with the after cursor: pass the next_cursor\nof a page to get the next one, it is absent on the last page.\nEvery field also takes operators as field[op]=value: eq and ne, gt, gte, lt and lte for age,\nin with a comma separated list (nationality[in]=RU,UA,BY) and null=true|false for a missing value.\nq takes comparisons (: or =, !=, \u003e, \u003e=, \u003c, \u003c=, ~ for a substring of a name, null for a missing value)\ncombined with AND, OR, NOT and parentheses; a malformed q is rejected with 400.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
                     "People"
@@ -105,12 +275,30 @@ const docTemplate = `{
                         "name": "age",
                         "in": "query"
                     },
+                    {
+                        "type": "integer",
+                        "description": "Minimum age",
+                        "name": "age[gte]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Age below",
+                        "name": "age[lt]",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Gender to filter by",
                         "name": "gender",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "Gender to exclude",
+                        "name": "gender[ne]",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Nationality to filter by",
@@ -118,45 +306,196 @@ const docTemplate = `{
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Page number",
-                        "name": "page",
+                        "type": "string",
+                        "description": "Comma separated nationalities",
+                        "name": "nationality[in]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Without (true) or with (false) a patronymic",
+                        "name": "patronymic[null]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Query expression, e.g. age\u003e=30 AND (nationality:RU OR nationality:KZ) AND surname~ov",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum enrichment probability (0-1) of every enriched field, people without a recorded gender or nationality probability do not match",
+                        "name": "min_confidence",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Match names across Cyrillic and Latin spellings (GOST 7.79, ICAO 9303)",
+                        "name": "translit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated sort fields (id, name, surname, patronymic, age, gender, nationality), - for descending, e.g. surname,-age",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page, next_cursor of the previous one",
+                        "name": "after",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Number of results per page",
+                        "description": "Number of results per page, 20 by default, larger values are lowered to 100",
                         "name": "limit",
                         "in": "query"
                     }
                 ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PeoplePage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/person/import": {
+            "post": {
+                "description": "Create many people at once. Names are enriched with batch requests to the enrichment APIs.\nThe result of every person is reported separately, in the order of the request.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "People"
+                ],
+                "summary": "Import many people",
+                "parameters": [
+                    {
+                        "description": "People to import",
+                        "name": "people",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entities.ReceivedPerson"
+                            }
+                        }
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "$ref": "#/definitions/responses.FoundPerson"
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/responses.ImportResult"
+                                }
                             }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/person/search": {
+            "get": {
+                "description": "Search over name, surname and patronymic. The fuzzy mode tolerates typos and ranks by trigram similarity.\nThe fulltext mode matches every word of q as a prefix of a name part, ranks by ts_rank and highlights the matches.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "People"
+                ],
+                "summary": "Search people by name",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name, surname or patronymic, possibly misspelled; several words in fulltext mode",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "fuzzy",
+                            "fulltext"
+                        ],
+                        "type": "string",
+                        "description": "Search mode, fuzzy by default",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum similarity (0-1) in fuzzy mode, 0.3 by default",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "type": "string"
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/entities.SearchHit"
+                                }
                             }
                         }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
                     }
                 }
             }
         },
         "/person/{id}": {
             "get": {
-                "description": "Get a single person by their ID",
+                "description": "Get a single person by their ID, including the provenance of enriched fields",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
                     "People"
@@ -181,13 +520,16 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     }
                 }
@@ -195,7 +537,8 @@ const docTemplate = `{
             "delete": {
                 "description": "Delete a single person from the database by their ID",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
                     "People"
@@ -220,24 +563,28 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "409": {
-                        "description": "Conflict",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     }
                 }
             },
             "patch": {
-                "description": "Update person's information by ID. Only provided fields will be updated.",
+                "description": "Update person's information by ID. Only provided fields will be updated.\nAge, gender and nationality set here are locked against automatic enrichment.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
                     "People"
@@ -257,7 +604,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/entities.Person"
+                            "$ref": "#/definitions/entities.PersonPatch"
                         }
                     }
                 ],
@@ -274,26 +621,194 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     },
                     "409": {
                         "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/person/{id}/locks/{field}": {
+            "put": {
+                "description": "Protect age, gender or nationality of a person from being overwritten by enrichment",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "People"
+                ],
+                "summary": "Lock a field against enrichment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Person ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "age",
+                            "gender",
+                            "nationality"
+                        ],
+                        "type": "string",
+                        "description": "Field to lock",
+                        "name": "field",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "type": "string"
+                                "$ref": "#/definitions/responses.ResponseMessage"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Let enrichment manage age, gender or nationality of a person again",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "People"
+                ],
+                "summary": "Unlock a field",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Person ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "age",
+                            "gender",
+                            "nationality"
+                        ],
+                        "type": "string",
+                        "description": "Field to unlock",
+                        "name": "field",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/responses.ResponseMessage"
                             }
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
                     }
                 }
             }
         }
     },
     "definitions": {
+        "entities.Candidate": {
+            "type": "object",
+            "properties": {
+                "probability": {
+                    "type": "number"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.JobStatus": {
+            "description": "State and progress of a background job",
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "interval": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_finished_at": {
+                    "type": "string"
+                },
+                "last_started_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "runs": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
         "entities.Person": {
             "description": "Person information with age, gender and nationality",
             "type": "object",
@@ -301,9 +816,26 @@ const docTemplate = `{
                 "age": {
                     "type": "integer"
                 },
+                "enrichment": {
+                    "description": "keyed by field",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/entities.Provenance"
+                    }
+                },
                 "gender": {
                     "type": "string"
                 },
+                "id": {
+                    "type": "integer"
+                },
+                "locked": {
+                    "description": "fields enrichment must not overwrite",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "name": {
                     "type": "string"
                 },
@@ -313,11 +845,216 @@ const docTemplate = `{
                 "patronymic": {
                     "type": "string"
                 },
+                "pending": {
+                    "description": "fields still waiting for enrichment",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "surname": {
                     "type": "string"
                 }
             }
         },
+        "entities.PersonPatch": {
+            "description": "Fields of a person to update",
+            "type": "object",
+            "properties": {
+                "age": {
+                    "type": "integer"
+                },
+                "gender": {
+                    "type": "string"
+                },
+                "locked": {
+                    "description": "fields enrichment must not overwrite",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nationality": {
+                    "type": "string"
+                },
+                "patronymic": {
+                    "type": "string"
+                },
+                "surname": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Provenance": {
+            "description": "Source and confidence of an enriched field",
+            "type": "object",
+            "properties": {
+                "candidates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Candidate"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "country_hint": {
+                    "type": "string"
+                },
+                "enriched_at": {
+                    "type": "string"
+                },
+                "probability": {
+                    "type": "number"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "rule": {
+                    "description": "rule that inferred the value without a provider call",
+                    "type": "string"
+                }
+            }
+        },
+        "entities.ReceivedPerson": {
+            "description": "Person information sent by client",
+            "type": "object",
+            "properties": {
+                "country_id": {
+                    "description": "ISO 3166-1 alpha-2 hint for age and gender prediction",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "patronymic": {
+                    "type": "string"
+                },
+                "surname": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.SearchHit": {
+            "description": "Person matching a search query, ranked by score",
+            "type": "object",
+            "properties": {
+                "age": {
+                    "type": "integer"
+                },
+                "enrichment": {
+                    "description": "keyed by field",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/entities.Provenance"
+                    }
+                },
+                "gender": {
+                    "type": "string"
+                },
+                "highlight": {
+                    "description": "full name with matches wrapped in \u003cmark\u003e, full-text only",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "locked": {
+                    "description": "fields enrichment must not overwrite",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nationality": {
+                    "type": "string"
+                },
+                "patronymic": {
+                    "type": "string"
+                },
+                "pending": {
+                    "description": "fields still waiting for enrichment",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "score": {
+                    "description": "trigram similarity (0 to 1) or full-text rank",
+                    "type": "number"
+                },
+                "surname": {
+                    "type": "string"
+                }
+            }
+        },
+        "extractors.BreakerState": {
+            "type": "string",
+            "enum": [
+                "closed",
+                "open",
+                "half-open"
+            ],
+            "x-enum-varnames": [
+                "StateClosed",
+                "StateOpen",
+                "StateHalfOpen"
+            ]
+        },
+        "extractors.BreakerStatus": {
+            "type": "object",
+            "properties": {
+                "consecutive_failures": {
+                    "type": "integer"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "opened_at": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "state": {
+                    "$ref": "#/definitions/extractors.BreakerState"
+                }
+            }
+        },
+        "extractors.CacheStats": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "integer"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "responses.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
         "responses.FoundPerson": {
             "type": "object",
             "properties": {
@@ -329,6 +1066,77 @@ const docTemplate = `{
                 }
             }
         },
+        "responses.ImportResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "pending": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "responses.PeoplePage": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Person"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "next_cursor": {
+                    "description": "pass as after to get the next page, absent on the last page",
+                    "type": "string"
+                }
+            }
+        },
+        "responses.Problem": {
+            "description": "Problem details of a failed request (RFC 7807), sent as application/problem+json",
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "description": "explanation of this occurrence",
+                    "type": "string"
+                },
+                "errors": {
+                    "description": "set for validation failures",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.FieldError"
+                    }
+                },
+                "instance": {
+                    "description": "request URI the problem occurred at",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "HTTP status code",
+                    "type": "integer"
+                },
+                "title": {
+                    "description": "short summary, the same for every problem of a type",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "URI reference identifying the problem type",
+                    "type": "string"
+                }
+            }
+        },
         "responses.ResponseMessage": {
             "type": "object",
             "properties": {
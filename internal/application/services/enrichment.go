@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/interfaces"
+)
+
+// enrichAll enriches people in batches when the enricher supports them
+func enrichAll(ctx context.Context, enricher interfaces.Enricher, people []*entities.Person) []error {
+	if batch, ok := enricher.(interfaces.BatchEnricher); ok {
+		return batch.EnrichBatch(ctx, people)
+	}
+
+	errs := make([]error, len(people))
+	for i, person := range people {
+		errs[i] = enricher.Enrich(ctx, person)
+	}
+
+	return errs
+}
+
+// failedFields returns the requested fields enrichment could not resolve
+func failedFields(err error, requested []string) []string {
+	if err == nil {
+		return nil
+	}
+
+	var fieldErr interface{ Fields() []string }
+	if errors.As(err, &fieldErr) {
+		return fieldErr.Fields()
+	}
+
+	return requested
+}
+
+// unknownFields returns the fields the providers answered for without
+// knowing the name, which retrying later will not change. A failure to reach
+// a provider is not one of them.
+func unknownFields(err error) []string {
+	var fieldErr interface{ UnknownFields() []string }
+	if errors.As(err, &fieldErr) {
+		return fieldErr.UnknownFields()
+	}
+
+	return nil
+}
+
+// enrichedUpdate builds a patch carrying the given fields of enriched and their provenance
+func enrichedUpdate(enriched entities.Person, fields []string) entities.Person {
+	update := entities.Person{}
+	for _, field := range fields {
+		switch field {
+		case entities.FieldAge:
+			update.Age = enriched.Age
+		case entities.FieldGender:
+			update.Gender = enriched.Gender
+		case entities.FieldNationality:
+			update.Nationality = enriched.Nationality
+		}
+
+		if provenance, ok := enriched.Enrichment[field]; ok {
+			if update.Enrichment == nil {
+				update.Enrichment = make(map[string]entities.Provenance)
+			}
+
+			update.Enrichment[field] = provenance
+		}
+	}
+
+	return update
+}
+
+// without returns fields except the excluded ones
+func without(fields, excluded []string) []string {
+	var rest []string
+	for _, field := range fields {
+		if !slices.Contains(excluded, field) {
+			rest = append(rest, field)
+		}
+	}
+
+	return rest
+}
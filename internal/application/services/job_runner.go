@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/agl/fio/internal/domain/apperrors"
+	"github.com/agl/fio/internal/domain/entities"
+	. "github.com/agl/fio/pkg/logger"
+)
+
+// Job is a unit of background work run periodically by a JobRunner
+type Job interface {
+	Name() string
+	Run(ctx context.Context, progress *JobProgress) error
+}
+
+// JobProgress counts the records a job run went through
+type JobProgress struct {
+	total     atomic.Int64
+	processed atomic.Int64
+	updated   atomic.Int64
+	failed    atomic.Int64
+}
+
+func (p *JobProgress) AddTotal(n int)     { p.total.Add(int64(n)) }
+func (p *JobProgress) AddProcessed(n int) { p.processed.Add(int64(n)) }
+func (p *JobProgress) AddUpdated(n int)   { p.updated.Add(int64(n)) }
+func (p *JobProgress) AddFailed(n int)    { p.failed.Add(int64(n)) }
+
+type scheduledJob struct {
+	job      Job
+	interval time.Duration
+	trigger  chan struct{}
+
+	mu       sync.Mutex
+	status   entities.JobStatus
+	progress *JobProgress
+}
+
+// JobRunner runs every scheduled job on its own interval, one run of a job at a time
+type JobRunner struct {
+	jobs []*scheduledJob
+}
+
+func NewJobRunner() *JobRunner {
+	return &JobRunner{}
+}
+
+// Schedule registers job to run every interval once the runner is started
+func (r *JobRunner) Schedule(job Job, interval time.Duration) {
+	r.jobs = append(r.jobs, &scheduledJob{
+		job:      job,
+		interval: interval,
+		trigger:  make(chan struct{}, 1),
+		status:   entities.JobStatus{Name: job.Name(), Interval: interval.String()},
+		progress: &JobProgress{},
+	})
+}
+
+// Start runs the scheduled jobs until ctx is cancelled
+func (r *JobRunner) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, s := range r.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.loop(ctx, s)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// Trigger starts a run of the named job without waiting for its interval
+func (r *JobRunner) Trigger(name string) error {
+	for _, s := range r.jobs {
+		if s.job.Name() != name {
+			continue
+		}
+
+		s.mu.Lock()
+		running := s.status.Running
+		s.mu.Unlock()
+		if running {
+			return apperrors.Conflict("Job is already running", nil)
+		}
+
+		select {
+		case s.trigger <- struct{}{}:
+		default:
+		}
+
+		return nil
+	}
+
+	return apperrors.NotFound("Job not found")
+}
+
+// Statuses reports the state and progress of every scheduled job
+func (r *JobRunner) Statuses() []entities.JobStatus {
+	statuses := make([]entities.JobStatus, 0, len(r.jobs))
+	for _, s := range r.jobs {
+		s.mu.Lock()
+		status := s.status
+		progress := s.progress
+		s.mu.Unlock()
+
+		status.Total = progress.total.Load()
+		status.Processed = progress.processed.Load()
+		status.Updated = progress.updated.Load()
+		status.Failed = progress.failed.Load()
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+func (r *JobRunner) loop(ctx context.Context, s *scheduledJob) {
+	Log.Info("Job scheduled", "job", s.job.Name(), "interval", s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			Log.Info("Job stopped", "job", s.job.Name())
+
+			return
+		case <-ticker.C:
+		case <-s.trigger:
+		}
+
+		r.run(ctx, s)
+	}
+}
+
+func (r *JobRunner) run(ctx context.Context, s *scheduledJob) {
+	progress := &JobProgress{}
+	started := time.Now().UTC()
+
+	s.mu.Lock()
+	s.progress = progress
+	s.status.Running = true
+	s.status.Runs++
+	s.status.LastStartedAt = &started
+	s.status.LastError = ""
+	s.mu.Unlock()
+
+	err := s.job.Run(ctx, progress)
+
+	finished := time.Now().UTC()
+
+	s.mu.Lock()
+	s.status.Running = false
+	s.status.LastFinishedAt = &finished
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		Log.Info("Job run failed", "job", s.job.Name(), "error", err)
+
+		return
+	}
+
+	Log.Debug("Job run finished", "job", s.job.Name(), "duration", finished.Sub(started),
+		"processed", progress.processed.Load(), "updated", progress.updated.Load(), "failed", progress.failed.Load())
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/agl/fio/internal/domain/apperrors"
+	"github.com/agl/fio/internal/domain/entities"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// encodeCursor makes the cursor opaque to clients, they only pass it back
+func encodeCursor(cursor entities.Cursor) string {
+	data, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads a cursor made by encodeCursor for the same sort
+func decodeCursor(after, sort string) (*entities.Cursor, error) {
+	invalid := apperrors.InvalidField("after", "is not a valid cursor for this sort")
+
+	data, err := base64.RawURLEncoding.DecodeString(after)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var cursor entities.Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sort != sort {
+		return nil, invalid
+	}
+
+	return &cursor, nil
+}
+
+// sortString writes sort keys the way parseSort reads them
+func sortString(keys []entities.SortKey) string {
+	items := make([]string, len(keys))
+	for i, key := range keys {
+		items[i] = key.Field
+		if key.Desc {
+			items[i] = "-" + key.Field
+		}
+	}
+
+	return strings.Join(items, ",")
+}
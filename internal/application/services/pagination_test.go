@@ -0,0 +1,73 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/agl/fio/internal/domain/entities"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	text := func(s string) *string { return &s }
+
+	tests := []struct {
+		name   string
+		cursor entities.Cursor
+	}{
+		{"id only", entities.Cursor{ID: 42}},
+		{"present values", entities.Cursor{Sort: "surname,-age", Values: []*string{text("петров"), text("30")}, ID: 7}},
+		{"missing values", entities.Cursor{Sort: "-patronymic,age", Values: []*string{nil, nil}, ID: 3}},
+		{"missing then present", entities.Cursor{Sort: "age,nationality", Values: []*string{nil, text("RU")}, ID: 5}},
+		{"present then missing", entities.Cursor{Sort: "-gender,age", Values: []*string{text("male"), nil}, ID: 9}},
+		{"empty string is not missing", entities.Cursor{Sort: "patronymic", Values: []*string{text("")}, ID: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(encodeCursor(tt.cursor), tt.cursor.Sort)
+			if err != nil {
+				t.Fatalf("decodeCursor error: %v", err)
+			}
+
+			if !reflect.DeepEqual(*got, tt.cursor) {
+				t.Errorf("decodeCursor(encodeCursor(%+v)) = %+v", tt.cursor, *got)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	valid := encodeCursor(entities.Cursor{Sort: "surname", Values: []*string{nil}, ID: 1})
+
+	tests := []struct {
+		name  string
+		after string
+		sort  string
+	}{
+		{"another sort", valid, "-surname"},
+		{"no sort", valid, ""},
+		{"not base64", "!!!", "surname"},
+		{"not json", "bm90IGpzb24", "surname"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.after, tt.sort); err == nil {
+				t.Errorf("decodeCursor(%q, %q) accepted an invalid cursor", tt.after, tt.sort)
+			}
+		})
+	}
+}
+
+func TestSortString(t *testing.T) {
+	for _, sort := range []string{"", "id", "-id", "surname,-age", "-nationality,name,-id"} {
+		keys, err := parseSort(sort)
+		if err != nil {
+			t.Fatalf("parseSort(%q) error: %v", sort, err)
+		}
+
+		if got := sortString(keys); got != sort {
+			t.Errorf("sortString(parseSort(%q)) = %q", sort, got)
+		}
+	}
+}
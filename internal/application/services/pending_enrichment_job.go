@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/interfaces"
+	. "github.com/agl/fio/pkg/logger"
+)
+
+const (
+	DefaultWorkerInterval    = time.Minute
+	DefaultWorkerBatchSize   = 100
+	DefaultWorkerMaxAttempts = 5
+)
+
+// PendingEnrichmentJob retries enrichment of people saved with pending
+// fields and patches the records with whatever it gets
+type PendingEnrichmentJob struct {
+	repo        interfaces.PersonRepository
+	enricher    interfaces.Enricher
+	batchSize   int
+	maxAttempts int
+}
+
+func NewPendingEnrichmentJob(repo interfaces.PersonRepository, enricher interfaces.Enricher, batchSize, maxAttempts int) *PendingEnrichmentJob {
+	if batchSize <= 0 {
+		batchSize = DefaultWorkerBatchSize
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultWorkerMaxAttempts
+	}
+
+	return &PendingEnrichmentJob{
+		repo:        repo,
+		enricher:    enricher,
+		batchSize:   batchSize,
+		maxAttempts: maxAttempts,
+	}
+}
+
+func (j *PendingEnrichmentJob) Name() string {
+	return "pending-enrichment"
+}
+
+// Run processes one batch of people pending enrichment
+func (j *PendingEnrichmentJob) Run(ctx context.Context, progress *JobProgress) error {
+	people, err := j.repo.GetPendingEnrichment(ctx, j.batchSize, j.maxAttempts)
+	if err != nil {
+		Log.Info("Failed to get people pending enrichment", "error", err)
+
+		return err
+	}
+
+	progress.AddTotal(len(people))
+
+	enriched := make([]entities.Person, len(people))
+	ptrs := make([]*entities.Person, len(people))
+	for i := range people {
+		enriched[i] = people[i]
+		ptrs[i] = &enriched[i]
+	}
+
+	errs := enrichAll(ctx, j.enricher, ptrs)
+
+	for i, person := range people {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		j.apply(ctx, person, enriched[i], errs[i], progress)
+		progress.AddProcessed(1)
+	}
+
+	return nil
+}
+
+// apply patches person with the fields resolved by enrichment
+func (j *PendingEnrichmentJob) apply(ctx context.Context, person, enriched entities.Person, err error, progress *JobProgress) {
+	pending := without(person.Pending, person.Locked)
+	failed := failedFields(err, pending)
+	resolved := without(pending, failed)
+
+	if len(resolved) > 0 {
+		update := enrichedUpdate(enriched, resolved)
+		// a field locked while the providers were queried is not written
+		if _, err := j.repo.UpdateEnrichedPerson(ctx, person.ID, update); err != nil {
+			Log.Info("Failed to patch enriched person", "id", person.ID, "error", err)
+			progress.AddFailed(1)
+
+			return
+		}
+
+		Log.Info("Pending enrichment resolved", "id", person.ID, "person", update)
+		progress.AddUpdated(1)
+	}
+
+	if len(failed) == 0 {
+		return
+	}
+
+	Log.Info("Enrichment still pending", "id", person.ID, "pending", failed, "error", err)
+	progress.AddFailed(1)
+
+	// only a name the providers do not know uses up an attempt, an outage is
+	// retried until the providers are back
+	if len(unknownFields(err)) == 0 {
+		return
+	}
+
+	if err := j.repo.RecordEnrichmentAttempt(ctx, person.ID); err != nil {
+		Log.Info("Failed to record enrichment attempt", "id", person.ID, "error", err)
+	}
+}
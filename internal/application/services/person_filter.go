@@ -0,0 +1,273 @@
+package services
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/agl/fio/internal/domain/apperrors"
+	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/names"
+	"github.com/agl/fio/internal/domain/query"
+)
+
+var (
+	textOps    = []string{entities.OpEq, entities.OpNe, entities.OpIn, entities.OpNull}
+	numericOps = []string{entities.OpEq, entities.OpNe, entities.OpGt, entities.OpGte, entities.OpLt, entities.OpLte, entities.OpIn, entities.OpNull}
+	booleans   = oneOf("true", "false")
+)
+
+// filterField describes the operators a filter field accepts and how its
+// values are normalized and validated
+type filterField struct {
+	ops       []string
+	normalize func(string) string
+	rules     []rule
+}
+
+// filterFields are the fields a filter can match. Unlike a stored person
+// the age filter accepts 0, the age of infants.
+var filterFields = map[string]filterField{
+	"name":                    {textOps, names.Normalize, nameRules},
+	"surname":                 {textOps, names.Normalize, nameRules},
+	"patronymic":              {textOps, names.Normalize, nameRules},
+	entities.FieldAge:         {numericOps, strings.TrimSpace, []rule{intRange(0, maxAge)}},
+	entities.FieldGender:      {textOps, strings.TrimSpace, genderRules},
+	entities.FieldNationality: {textOps, countryCodeOf, countryRules},
+}
+
+func countryCodeOf(value string) string {
+	return strings.ToUpper(strings.TrimSpace(value))
+}
+
+// parseFilter validates the raw filter params and the q expression and
+// builds the typed filter. An empty value leaves its operator unset, in takes
+// a comma separated list and null takes true or false.
+func parseFilter(params entities.FilterParams, q, minConfidenceStr, translitStr string) (entities.PersonFilter, error) {
+	var expr query.Node
+	if strings.TrimSpace(q) != "" {
+		var err error
+		expr, err = query.Parse(q)
+		if err == nil {
+			err = checkQuery(expr)
+		}
+		if err != nil {
+			return entities.PersonFilter{}, apperrors.Wrap(apperrors.ErrBadRequest, "Invalid query", err).WithDetails(err.Error())
+		}
+
+		query.Walk(expr, normalizeComparison)
+	}
+
+	fields := []field{
+		{"min_confidence", minConfidenceStr, []rule{floatRange(0, 1)}},
+		{"translit", translitStr, []rule{booleans}},
+	}
+	for _, name := range slices.Sorted(maps.Keys(params)) {
+		spec, ok := filterFields[name]
+		if !ok {
+			fields = append(fields, field{name, name, []rule{oneOf(entities.FilterFields...)}})
+			continue
+		}
+
+		for _, op := range slices.Sorted(maps.Keys(params[name])) {
+			raw := params[name][op]
+			key := name + "[" + op + "]"
+
+			switch {
+			case !slices.Contains(spec.ops, op):
+				fields = append(fields, field{key, op, []rule{oneOf(spec.ops...)}})
+			case raw == "":
+			case op == entities.OpNull:
+				fields = append(fields, field{key, raw, []rule{booleans}})
+			case op == entities.OpIn:
+				values := strings.Join(filterValues(spec, op, raw), ",")
+				fields = append(fields, field{key, values, []rule{each(append([]rule{required}, spec.rules...))}})
+			default:
+				fields = append(fields, field{key, spec.normalize(raw), spec.rules})
+			}
+		}
+	}
+
+	if err := validate(fields...); err != nil {
+		return entities.PersonFilter{}, err
+	}
+
+	filter := entities.PersonFilter{Query: expr, Translit: translitStr == "true"}
+	if minConfidenceStr != "" {
+		filter.MinConfidence, _ = strconv.ParseFloat(minConfidenceStr, 64)
+	}
+
+	text := map[string]*entities.StringFilter{
+		"name":                    &filter.Name,
+		"surname":                 &filter.Surname,
+		"patronymic":              &filter.Patronymic,
+		entities.FieldGender:      &filter.Gender,
+		entities.FieldNationality: &filter.Nationality,
+	}
+	for name, ops := range params {
+		for op, raw := range ops {
+			if raw == "" {
+				continue
+			}
+
+			if op == entities.OpNull {
+				null := raw == "true"
+				if name == entities.FieldAge {
+					filter.Age.Null = &null
+				} else {
+					text[name].Null = &null
+				}
+				continue
+			}
+
+			values := filterValues(filterFields[name], op, raw)
+			if name == entities.FieldAge {
+				setNumeric(&filter.Age, op, values)
+			} else {
+				setText(text[name], op, values)
+			}
+		}
+	}
+
+	return filter, nil
+}
+
+// checkQuery applies the rules of the numeric filter fields to the numbers
+// of a q expression, so age>99999999999 fails like age[gt]=99999999999
+func checkQuery(expr query.Node) error {
+	var err error
+	query.Walk(expr, func(cmp *query.Comparison) {
+		n, ok := cmp.Value.(int)
+		if !ok || err != nil {
+			return
+		}
+
+		for _, r := range filterFields[cmp.Field].rules {
+			if message := r(strconv.Itoa(n)); message != "" {
+				err = &query.Error{Pos: cmp.Pos, Message: cmp.Field + " " + message}
+
+				return
+			}
+		}
+	})
+
+	return err
+}
+
+// normalizeComparison brings a q value to the form the filter fields take
+func normalizeComparison(cmp *query.Comparison) {
+	value, ok := cmp.Value.(string)
+	if !ok {
+		return
+	}
+
+	if spec := filterFields[cmp.Field]; spec.normalize != nil {
+		cmp.Value = spec.normalize(value)
+	}
+}
+
+// each applies rules to every item of a comma separated list and reports the
+// first violation
+func each(rules []rule) rule {
+	return func(value string) string {
+		for _, item := range strings.Split(value, ",") {
+			for _, r := range rules {
+				if message := r(item); message != "" {
+					return message
+				}
+			}
+		}
+
+		return ""
+	}
+}
+
+// filterValues splits the list of the in operator and normalizes the values
+func filterValues(spec filterField, op, raw string) []string {
+	values := []string{raw}
+	if op == entities.OpIn {
+		values = strings.Split(raw, ",")
+	}
+
+	for i, value := range values {
+		values[i] = spec.normalize(value)
+	}
+
+	return values
+}
+
+func setText(f *entities.StringFilter, op string, values []string) {
+	switch op {
+	case entities.OpEq:
+		f.Eq = &values[0]
+	case entities.OpNe:
+		f.Ne = &values[0]
+	case entities.OpIn:
+		f.In = values
+	}
+}
+
+func setNumeric(f *entities.IntFilter, op string, values []string) {
+	numbers := make([]int, len(values))
+	for i, value := range values {
+		numbers[i], _ = strconv.Atoi(value)
+	}
+
+	switch op {
+	case entities.OpEq:
+		f.Eq = &numbers[0]
+	case entities.OpNe:
+		f.Ne = &numbers[0]
+	case entities.OpGt:
+		f.Gt = &numbers[0]
+	case entities.OpGte:
+		f.Gte = &numbers[0]
+	case entities.OpLt:
+		f.Lt = &numbers[0]
+	case entities.OpLte:
+		f.Lte = &numbers[0]
+	case entities.OpIn:
+		f.In = numbers
+	}
+}
+
+// parseSort reads a comma separated list of sort fields, each prefixed with
+// - for descending order, e.g. surname,-age
+func parseSort(sortStr string) ([]entities.SortKey, error) {
+	if err := validate(field{"sort", sortStr, []rule{sortKeys}}); err != nil {
+		return nil, err
+	}
+
+	if sortStr == "" {
+		return nil, nil
+	}
+
+	var keys []entities.SortKey
+	for _, item := range strings.Split(sortStr, ",") {
+		item = strings.TrimSpace(item)
+		keys = append(keys, entities.SortKey{Field: strings.TrimPrefix(item, "-"), Desc: strings.HasPrefix(item, "-")})
+	}
+
+	return keys, nil
+}
+
+func sortKeys(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	seen := map[string]bool{}
+	for _, item := range strings.Split(value, ",") {
+		name := strings.TrimPrefix(strings.TrimSpace(item), "-")
+		if !slices.Contains(entities.SortFields, name) {
+			return "must list fields of " + strings.Join(entities.SortFields, ", ") + ", prefixed with - for descending order"
+		}
+		if seen[name] {
+			return "must not repeat " + name
+		}
+		seen[name] = true
+	}
+
+	return ""
+}
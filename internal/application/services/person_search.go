@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/names"
+	. "github.com/agl/fio/pkg/logger"
+)
+
+// Search modes
+const (
+	SearchFuzzy    = "fuzzy"
+	SearchFullText = "fulltext"
+)
+
+const (
+	DefaultSearchThreshold = 0.3
+	DefaultSearchLimit     = 20
+	MaxSearchLimit         = 100
+	MaxSearchWords         = 10
+)
+
+// SearchPeople finds people by name. The fuzzy mode tolerates typos in a
+// single name, the fulltext mode matches every word of q as a prefix of any
+// name part. Empty mode, threshold and limit fall back to the defaults.
+func (p *PersonService) SearchPeople(ctx context.Context, q, mode, thresholdStr, limitStr string) ([]entities.SearchHit, error) {
+	q = names.Normalize(q)
+	if mode == "" {
+		mode = SearchFuzzy
+	}
+
+	err := validate(
+		field{"q", q, []rule{required, maxLength(3 * maxNameLength), maxWords(MaxSearchWords)}},
+		field{"mode", mode, []rule{oneOf(SearchFuzzy, SearchFullText)}},
+		field{"threshold", thresholdStr, []rule{floatRange(0, 1)}},
+		field{"limit", limitStr, []rule{intRange(1, MaxSearchLimit)}},
+	)
+	if err != nil {
+		Log.Info("Invalid search request", "q", q, "error", err)
+
+		return nil, err
+	}
+
+	threshold := DefaultSearchThreshold
+	if thresholdStr != "" {
+		threshold, _ = strconv.ParseFloat(thresholdStr, 64)
+	}
+
+	limit := DefaultSearchLimit
+	if limitStr != "" {
+		limit, _ = strconv.Atoi(limitStr)
+	}
+
+	hits := []entities.SearchHit{}
+	switch words := searchWords(q); {
+	case mode == SearchFuzzy:
+		hits, err = p.repo.SearchPeople(ctx, q, threshold, limit)
+	case len(words) > 0:
+		hits, err = p.repo.FullTextSearchPeople(ctx, words, limit)
+	}
+	if err != nil {
+		Log.Info("Failed to search people", "q", q, "error", err)
+
+		return nil, err
+	}
+
+	Log.Info("People searched successfully", "q", q, "mode", mode, "count", len(hits))
+
+	return hits, nil
+}
+
+// searchWords splits q into lowercase words of letters and digits, the only
+// characters safe to pass to to_tsquery
+func searchWords(q string) []string {
+	return strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -1,11 +1,15 @@
 package services
 
 import (
-	"errors"
+	"context"
+	"slices"
 	"strconv"
+	"strings"
 
+	"github.com/agl/fio/internal/domain/apperrors"
 	"github.com/agl/fio/internal/domain/entities"
 	"github.com/agl/fio/internal/domain/interfaces"
+	"github.com/agl/fio/internal/domain/names"
 	. "github.com/agl/fio/pkg/logger"
 )
 
@@ -17,16 +21,46 @@ func NewPersonService(repo interfaces.PersonRepository) *PersonService {
 	return &PersonService{repo: repo}
 }
 
-func (p *PersonService) UpdatePersonByID(person entities.Person, idStr string) error {
+// UpdatePersonByID applies a client's patch. The patch carries no
+// provenance, so enrichment data can only come from the enrichment itself.
+func (p *PersonService) UpdatePersonByID(ctx context.Context, patch entities.PersonPatch, idStr string) error {
 	id, err := strconv.Atoi(idStr)
 
 	if err != nil {
 		Log.Info("Invalid ID format", "idStr", idStr, "error", err)
 
-		return errors.New("invalid id format")
+		return apperrors.InvalidField("id", "must be an integer")
 	}
 
-	err = p.repo.UpdatePersonByID(id, person)
+	person := patch.Person()
+	normalizePerson(&person)
+	if err := validatePerson(person, false); err != nil {
+		Log.Info("Invalid person update", "id", id, "error", err)
+
+		return err
+	}
+
+	for _, field := range person.Locked {
+		if !slices.Contains(entities.EnrichedFields, field) {
+			Log.Info("Invalid locked field", "id", id, "field", field)
+
+			return apperrors.InvalidField("locked", "only age, gender and nationality can be locked, got "+field)
+		}
+	}
+
+	// fields edited by hand are protected from enrichment
+	person.Locked = slices.Clone(person.Locked)
+	if person.Age != 0 {
+		person.Locked = append(person.Locked, entities.FieldAge)
+	}
+	if person.Gender != "" {
+		person.Locked = append(person.Locked, entities.FieldGender)
+	}
+	if person.Nationality != "" {
+		person.Locked = append(person.Locked, entities.FieldNationality)
+	}
+
+	err = p.repo.UpdatePersonByID(ctx, id, person)
 	if err != nil {
 		Log.Info("Failed to update person", "id", id, "error", err, "person", person)
 
@@ -38,8 +72,33 @@ func (p *PersonService) UpdatePersonByID(person entities.Person, idStr string) e
 	return nil
 }
 
-func (p *PersonService) CreatePerson(person entities.Person) (int, error) {
-	id, err := p.repo.CreatePerson(person)
+// ValidateReceivedPerson normalizes and checks a person sent for creation,
+// before any enrichment is spent on it. The normalized person is returned so
+// the enrichment APIs and their cache see the stored spelling.
+func (p *PersonService) ValidateReceivedPerson(ctx context.Context, person entities.ReceivedPerson) (entities.ReceivedPerson, error) {
+	person.Name = names.Normalize(person.Name)
+	person.Surname = names.Normalize(person.Surname)
+	person.Patronymic = newPatronymic(person.Patronymic)
+
+	if err := validateReceivedPerson(person); err != nil {
+		Log.Info("Invalid person", "person", person, "error", err)
+
+		return entities.ReceivedPerson{}, err
+	}
+
+	return person, nil
+}
+
+func (p *PersonService) CreatePerson(ctx context.Context, person entities.Person) (int, error) {
+	normalizePerson(&person)
+	person.Patronymic = newPatronymic(person.Patronymic)
+	if err := validatePerson(person, true); err != nil {
+		Log.Info("Invalid person", "person", person, "error", err)
+
+		return 0, err
+	}
+
+	id, err := p.repo.CreatePerson(ctx, person)
 
 	if err != nil {
 		Log.Info("Failed to create person", "error", err, "person", person)
@@ -52,16 +111,16 @@ func (p *PersonService) CreatePerson(person entities.Person) (int, error) {
 	return id, nil
 }
 
-func (p *PersonService) GetPersonByID(idStr string) (entities.Person, error) {
+func (p *PersonService) GetPersonByID(ctx context.Context, idStr string) (entities.Person, error) {
 	id, err := strconv.Atoi(idStr)
 
 	if err != nil {
 		Log.Info("Invalid ID format", "id", idStr, "error", err)
 
-		return entities.Person{}, errors.New("invalid id format")
+		return entities.Person{}, apperrors.InvalidField("id", "must be an integer")
 	}
 
-	person, err := p.repo.GetPersonByID(id)
+	person, err := p.repo.GetPersonByID(ctx, id)
 
 	if err != nil {
 		Log.Info("Failed to get person by ID", "id", idStr, "error", err)
@@ -74,46 +133,81 @@ func (p *PersonService) GetPersonByID(idStr string) (entities.Person, error) {
 	return person, nil
 }
 
-func (p *PersonService) GetPeopleByFilter(name, surname, ageStr, gender, nationality, page, limit string, patronymic *string) ([]entities.Person, error) {
-	age, err := strconv.Atoi(ageStr)
+// GetPeopleByFilter lists people matching every filter operator of params
+// and the q expression, see parseFilter, in the order of sortStr. A page
+// starts after the after cursor and the cursor of the next page is returned,
+// empty on the last page. Empty limit falls back to the default and a limit
+// above the maximum is lowered to it.
+func (p *PersonService) GetPeopleByFilter(ctx context.Context, params entities.FilterParams, q, minConfidenceStr, translitStr, sortStr, after, limitStr string) ([]entities.Person, string, error) {
+	filter, err := parseFilter(params, q, minConfidenceStr, translitStr)
 
 	if err != nil {
-		Log.Info("Invalid age format", "age", ageStr, "error", err)
+		Log.Info("Invalid filter", "params", params, "q", q, "error", err)
 
-		return nil, errors.New("invalid age format")
+		return nil, "", err
 	}
 
-	person := entities.Person{
-		Name: name,
-		Surname: surname,
-		Patronymic: patronymic,
-		Age: age,
-		Gender: gender,
-		Nationality: nationality,
+	sort, err := parseSort(sortStr)
+
+	if err != nil {
+		Log.Info("Invalid sort", "sort", sortStr, "error", err)
+
+		return nil, "", err
 	}
 
-	people, err := p.repo.GetPeopleByFilter(person, page, limit)
+	err = validate(field{"limit", limitStr, []rule{positive}})
 
 	if err != nil {
-		Log.Info("Failed to get people by age", "age", ageStr, "error", err)
+		Log.Info("Invalid limit", "limit", limitStr, "error", err)
 
-		return nil, err
+		return nil, "", err
 	}
 
-	Log.Info("People received successfully by age", "age", ageStr, "page", page, "limit", limit)
+	limit := DefaultPageLimit
+	if limitStr != "" {
+		limit, _ = strconv.Atoi(limitStr)
+		limit = min(limit, MaxPageLimit)
+	}
+
+	var cursor *entities.Cursor
+	if after != "" {
+		cursor, err = decodeCursor(after, sortString(sort))
+
+		if err != nil {
+			Log.Info("Invalid cursor", "after", after, "error", err)
+
+			return nil, "", err
+		}
+	}
+
+	people, next, err := p.repo.GetPeopleByFilter(ctx, filter, sort, cursor, limit)
+
+	if err != nil {
+		Log.Info("Failed to get people by filter", "params", params, "error", err)
 
-	return people, nil
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if next != nil {
+		next.Sort = sortString(sort)
+		nextCursor = encodeCursor(*next)
+	}
+
+	Log.Info("People received successfully by filter", "params", params, "count", len(people), "limit", limit)
+
+	return people, nextCursor, nil
 }
 
-func (p *PersonService) DeletePersonByID(idStr string) error {
+func (p *PersonService) DeletePersonByID(ctx context.Context, idStr string) error {
 	id, err := strconv.Atoi(idStr)
 
 	if err != nil {
 		Log.Info("Invalid ID format", "id", idStr, "error", err)
-		return errors.New("invalid id format")
+		return apperrors.InvalidField("id", "must be an integer")
 	}
 
-	err = p.repo.DeletePersonByID(id)
+	err = p.repo.DeletePersonByID(ctx, id)
 	if err != nil {
 		Log.Info("Failed to delete person", "id", id, "error", err)
 		return err
@@ -122,4 +216,58 @@ func (p *PersonService) DeletePersonByID(idStr string) error {
 	Log.Info("Person deleted successfully", "id", id)
 
 	return nil
-}
\ No newline at end of file
+}
+
+func (p *PersonService) SetFieldLock(ctx context.Context, idStr, field string, locked bool) ([]string, error) {
+	id, err := strconv.Atoi(idStr)
+
+	if err != nil {
+		Log.Info("Invalid ID format", "id", idStr, "error", err)
+
+		return nil, apperrors.InvalidField("id", "must be an integer")
+	}
+
+	if !slices.Contains(entities.EnrichedFields, field) {
+		Log.Info("Invalid lock field", "id", id, "field", field)
+
+		return nil, apperrors.InvalidField("field", "only age, gender and nationality can be locked")
+	}
+
+	fields, err := p.repo.SetFieldLock(ctx, id, field, locked)
+	if err != nil {
+		Log.Info("Failed to set field lock", "id", id, "field", field, "error", err)
+
+		return nil, err
+	}
+
+	Log.Info("Field lock set", "id", id, "field", field, "locked", locked)
+
+	return fields, nil
+}
+
+// normalizePerson brings names to their display form and the nationality to
+// an upper-case country code
+func normalizePerson(person *entities.Person) {
+	person.Name = names.Normalize(person.Name)
+	person.Surname = names.Normalize(person.Surname)
+	if person.Patronymic != nil {
+		patronymic := names.Normalize(*person.Patronymic)
+		person.Patronymic = &patronymic
+	}
+	person.Nationality = strings.ToUpper(person.Nationality)
+}
+
+// newPatronymic normalizes the patronymic of a new person, a blank one is
+// no patronymic at all. An update keeps it blank, which clears the field.
+func newPatronymic(patronymic *string) *string {
+	if patronymic == nil {
+		return nil
+	}
+
+	normalized := names.Normalize(*patronymic)
+	if normalized == "" {
+		return nil
+	}
+
+	return &normalized
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/interfaces"
+	. "github.com/agl/fio/pkg/logger"
+)
+
+const (
+	DefaultReenrichmentInterval      = 24 * time.Hour
+	DefaultReenrichmentMaxAge        = 90 * 24 * time.Hour
+	DefaultReenrichmentMinConfidence = 0.5
+	DefaultReenrichmentMinInterval   = 12 * time.Hour
+	DefaultReenrichmentBatchSize     = 100
+)
+
+// ReenrichmentOptions selects the records the re-enrichment job revisits
+type ReenrichmentOptions struct {
+	// MaxAge is how old provenance may get before the field is enriched again
+	MaxAge time.Duration
+	// MinConfidence is the probability below which a field is enriched again
+	MinConfidence float64
+	// MinInterval is how long a person is left alone after being checked
+	MinInterval time.Duration
+	BatchSize   int
+}
+
+// ReenrichmentJob re-runs enrichment for people whose provenance is stale
+// or not confident enough and records every value it replaces
+type ReenrichmentJob struct {
+	repo     interfaces.PersonRepository
+	enricher interfaces.Enricher
+	options  ReenrichmentOptions
+}
+
+func NewReenrichmentJob(repo interfaces.PersonRepository, enricher interfaces.Enricher, options ReenrichmentOptions) *ReenrichmentJob {
+	if options.MaxAge <= 0 {
+		options.MaxAge = DefaultReenrichmentMaxAge
+	}
+	if options.MinConfidence <= 0 {
+		options.MinConfidence = DefaultReenrichmentMinConfidence
+	}
+	if options.MinInterval <= 0 {
+		options.MinInterval = DefaultReenrichmentMinInterval
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultReenrichmentBatchSize
+	}
+
+	return &ReenrichmentJob{repo: repo, enricher: enricher, options: options}
+}
+
+func (j *ReenrichmentJob) Name() string {
+	return "reenrichment"
+}
+
+// Run walks every matching person in id order, one batch at a time
+func (j *ReenrichmentJob) Run(ctx context.Context, progress *JobProgress) error {
+	now := time.Now()
+	enrichedBefore := now.Add(-j.options.MaxAge)
+	checkedBefore := now.Add(-j.options.MinInterval)
+
+	afterID := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		people, err := j.repo.GetStaleEnrichment(ctx, enrichedBefore, j.options.MinConfidence, checkedBefore, afterID, j.options.BatchSize)
+		if err != nil {
+			Log.Info("Failed to get people with stale enrichment", "error", err)
+
+			return err
+		}
+		if len(people) == 0 {
+			return nil
+		}
+
+		progress.AddTotal(len(people))
+		j.processBatch(ctx, people, progress)
+
+		afterID = people[len(people)-1].ID
+	}
+}
+
+func (j *ReenrichmentJob) processBatch(ctx context.Context, people []entities.Person, progress *JobProgress) {
+	enriched := make([]entities.Person, len(people))
+	ptrs := make([]*entities.Person, len(people))
+	for i := range people {
+		enriched[i] = people[i]
+		enriched[i].Pending = nil
+		enriched[i].Enrichment = nil
+		ptrs[i] = &enriched[i]
+	}
+
+	errs := enrichAll(ctx, j.enricher, ptrs)
+
+	checked := make([]int, 0, len(people))
+	for i, person := range people {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if j.apply(ctx, person, enriched[i], errs[i], progress) {
+			checked = append(checked, person.ID)
+		}
+		progress.AddProcessed(1)
+	}
+
+	if err := j.repo.MarkEnrichmentChecked(ctx, checked); err != nil {
+		Log.Info("Failed to mark people checked", "error", err)
+	}
+}
+
+// apply writes the re-enriched fields of person and audits the changed
+// values. It reports whether the person should count as checked.
+func (j *ReenrichmentJob) apply(ctx context.Context, person, enriched entities.Person, err error, progress *JobProgress) bool {
+	fields := without(entities.EnrichedFields, person.Locked)
+	if len(fields) == 0 {
+		return true
+	}
+
+	resolved := without(fields, failedFields(err, fields))
+	if len(resolved) == 0 {
+		Log.Info("Re-enrichment failed", "id", person.ID, "error", err)
+		progress.AddFailed(1)
+
+		return false
+	}
+
+	update := enrichedUpdate(enriched, resolved)
+
+	// a field locked while the providers were queried is not written
+	skipped, err := j.repo.UpdateEnrichedPerson(ctx, person.ID, update)
+	if err != nil {
+		Log.Info("Failed to update re-enriched person", "id", person.ID, "error", err)
+		progress.AddFailed(1)
+
+		return false
+	}
+
+	changedAt := time.Now().UTC()
+	var audits []entities.EnrichmentAudit
+	for _, field := range without(resolved, skipped) {
+		oldValue, newValue := fieldValue(person, field), fieldValue(update, field)
+		if oldValue == newValue {
+			continue
+		}
+
+		audits = append(audits, entities.EnrichmentAudit{
+			PersonID:  person.ID,
+			Field:     field,
+			OldValue:  oldValue,
+			NewValue:  newValue,
+			Provider:  update.Enrichment[field].Provider,
+			Job:       j.Name(),
+			ChangedAt: changedAt,
+		})
+	}
+
+	if len(audits) == 0 {
+		return true
+	}
+
+	if err := j.repo.RecordEnrichmentAudit(ctx, audits); err != nil {
+		Log.Info("Failed to record enrichment audit", "id", person.ID, "error", err)
+	}
+
+	Log.Info("Person re-enriched", "id", person.ID, "changes", audits)
+	progress.AddUpdated(1)
+
+	return true
+}
+
+func fieldValue(person entities.Person, field string) string {
+	switch field {
+	case entities.FieldAge:
+		if person.Age == 0 {
+			return ""
+		}
+
+		return strconv.Itoa(person.Age)
+	case entities.FieldGender:
+		return person.Gender
+	case entities.FieldNationality:
+		return person.Nationality
+	}
+
+	return ""
+}
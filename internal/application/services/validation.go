@@ -0,0 +1,243 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/agl/fio/internal/domain/apperrors"
+	"github.com/agl/fio/internal/domain/entities"
+)
+
+const (
+	maxNameLength = 100 // people.name, surname and patronymic are VARCHAR(100)
+	minAge        = 1
+	maxAge        = 150
+)
+
+// rule checks one value and returns the violation message, empty when valid.
+// Every rule but required accepts an empty value.
+type rule func(value string) string
+
+// Rules of the validated fields, a field reports its first violation only
+var (
+	requiredNameRules = []rule{required, maxLength(maxNameLength), nameScript}
+	nameRules         = []rule{maxLength(maxNameLength), nameScript}
+	ageRules          = []rule{intRange(minAge, maxAge)}
+	genderRules       = []rule{oneOf(entities.GenderMale, entities.GenderFemale)}
+	countryRules      = []rule{countryCode}
+)
+
+type field struct {
+	name  string
+	value string
+	rules []rule
+}
+
+// validate applies the rules of every field and reports all violations at once
+func validate(fields ...field) error {
+	var err *apperrors.Error
+	var messages []string
+	for _, f := range fields {
+		for _, r := range f.rules {
+			if message := r(f.value); message != "" {
+				if err == nil {
+					err = apperrors.Validation("")
+				}
+				err.WithField(f.name, message)
+				messages = append(messages, f.name+" "+message)
+
+				break
+			}
+		}
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	err.Message = strings.Join(messages, "; ")
+
+	return err
+}
+
+// validateReceivedPerson checks a person sent for creation before it is enriched
+func validateReceivedPerson(person entities.ReceivedPerson) error {
+	return validate(
+		field{"name", person.Name, requiredNameRules},
+		field{"surname", person.Surname, requiredNameRules},
+		field{"patronymic", optional(person.Patronymic), nameRules},
+		field{"country_id", strings.ToUpper(optional(person.CountryID)), countryRules},
+	)
+}
+
+// validatePerson checks a complete person or a patch of one, zero values are
+// treated as absent
+func validatePerson(person entities.Person, requireName bool) error {
+	name := nameRules
+	if requireName {
+		name = requiredNameRules
+	}
+
+	age := ""
+	if person.Age != 0 {
+		age = strconv.Itoa(person.Age)
+	}
+
+	return validate(
+		field{"name", person.Name, name},
+		field{"surname", person.Surname, name},
+		field{"patronymic", optional(person.Patronymic), nameRules},
+		field{"age", age, ageRules},
+		field{"gender", person.Gender, genderRules},
+		field{"nationality", person.Nationality, countryRules},
+	)
+}
+
+func optional(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
+
+func required(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "is required"
+	}
+
+	return ""
+}
+
+func maxLength(n int) rule {
+	return func(value string) string {
+		if utf8.RuneCountInString(value) > n {
+			return "must be at most " + strconv.Itoa(n) + " characters"
+		}
+
+		return ""
+	}
+}
+
+func maxWords(n int) rule {
+	return func(value string) string {
+		if len(searchWords(value)) > n {
+			return "must contain at most " + strconv.Itoa(n) + " words"
+		}
+
+		return ""
+	}
+}
+
+// nameScript allows Latin or Cyrillic letters, not mixed, joined by single
+// spaces, hyphens and apostrophes
+func nameScript(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	const message = "must contain only Latin or Cyrillic letters, spaces, hyphens and apostrophes"
+
+	var latin, cyrillic bool
+	runes := []rune(value)
+	for i, r := range runes {
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin = true
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic = true
+		case r == ' ' || r == '-' || r == '\'' || r == '’':
+			if i == 0 || i == len(runes)-1 {
+				return "must start and end with a letter"
+			}
+		default:
+			return message
+		}
+	}
+
+	if strings.Contains(value, "  ") {
+		return message
+	}
+
+	if latin && cyrillic {
+		return "must not mix Latin and Cyrillic letters"
+	}
+
+	return ""
+}
+
+func intRange(min, max int) rule {
+	return func(value string) string {
+		if value == "" {
+			return ""
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < min || n > max {
+			return "must be between " + strconv.Itoa(min) + " and " + strconv.Itoa(max)
+		}
+
+		return ""
+	}
+}
+
+// positive accepts whole numbers from 1, however large, for values that are
+// clamped to a maximum rather than rejected
+func positive(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	n, err := strconv.Atoi(value)
+	if (err != nil && !errors.Is(err, strconv.ErrRange)) || n < 1 {
+		return "must be a positive integer"
+	}
+
+	return ""
+}
+
+func floatRange(min, max float64) rule {
+	return func(value string) string {
+		if value == "" {
+			return ""
+		}
+
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || f < min || f > max {
+			return "must be a number between " + strconv.FormatFloat(min, 'f', -1, 64) + " and " + strconv.FormatFloat(max, 'f', -1, 64)
+		}
+
+		return ""
+	}
+}
+
+func oneOf(allowed ...string) rule {
+	return func(value string) string {
+		if value == "" {
+			return ""
+		}
+
+		for _, a := range allowed {
+			if value == a {
+				return ""
+			}
+		}
+
+		return "must be one of " + strings.Join(allowed, ", ")
+	}
+}
+
+func countryCode(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	if _, ok := countryCodes[value]; !ok {
+		return "must be an ISO 3166-1 alpha-2 country code"
+	}
+
+	return ""
+}
@@ -0,0 +1,119 @@
+// Package apperrors defines the failure kinds the service reports to its
+// clients. Errors carry a message safe to show to a client; the underlying
+// cause is kept for logs only.
+package apperrors
+
+import (
+	"errors"
+	"time"
+)
+
+// Kinds of failures, match them with errors.Is
+var (
+	ErrBadRequest  = errors.New("bad request")
+	ErrValidation  = errors.New("validation failed")
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrUpstream    = errors.New("upstream failure")
+	ErrUnavailable = errors.New("temporarily unavailable")
+)
+
+// FieldError is a violation of a single input field
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Error is a failure of a known kind
+type Error struct {
+	Kind       error
+	Message    string        // safe to return to a client
+	Details    string        // optional client-facing explanation
+	Fields     []FieldError  // input fields that failed validation
+	RetryAfter time.Duration // when the client may retry an unavailable service
+	Err        error         // underlying cause, never returned to a client
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if e.Details != "" {
+		msg += ": " + e.Details
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an error of the given kind
+func New(kind error, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Wrap returns an error of the given kind caused by err
+func Wrap(kind error, message string, err error) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func (e *Error) WithDetails(details string) *Error {
+	e.Details = details
+
+	return e
+}
+
+func (e *Error) WithField(field, message string) *Error {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+
+	return e
+}
+
+func (e *Error) WithRetryAfter(retryAfter time.Duration) *Error {
+	e.RetryAfter = retryAfter
+
+	return e
+}
+
+func NotFound(message string) *Error {
+	return New(ErrNotFound, message)
+}
+
+func Validation(message string) *Error {
+	return New(ErrValidation, message)
+}
+
+// InvalidField reports a single invalid input field
+func InvalidField(field, message string) *Error {
+	return Validation(field+" "+message).WithField(field, message)
+}
+
+func Conflict(message string, err error) *Error {
+	return Wrap(ErrConflict, message, err)
+}
+
+func Upstream(message string, err error) *Error {
+	return Wrap(ErrUpstream, message, err)
+}
+
+// Message returns the client-safe message of err. Errors of unknown kind
+// get a generic message so internal details never reach the client.
+func Message(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		if appErr.Details != "" {
+			return appErr.Message + ": " + appErr.Details
+		}
+
+		return appErr.Message
+	}
+
+	return "Internal server error"
+}
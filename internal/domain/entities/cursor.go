@@ -0,0 +1,9 @@
+package entities
+
+// Cursor is the position after the last person of a page: the values of its
+// sort keys, nil when missing, and its id
+type Cursor struct {
+	Sort   string    `json:"s,omitempty"` // the sort the cursor was made for
+	Values []*string `json:"v,omitempty"`
+	ID     int       `json:"id"`
+}
@@ -0,0 +1,32 @@
+package entities
+
+import "time"
+
+// Provenance describes where an enriched field came from and how sure the provider was
+// @Description Source and confidence of an enriched field
+type Provenance struct {
+	Provider    string      `json:"provider"`
+	Probability *float64    `json:"probability,omitempty"`
+	Count       *int        `json:"count,omitempty"`
+	Candidates  []Candidate `json:"candidates,omitempty"`
+	CountryHint string      `json:"country_hint,omitempty"`
+	Rule        string      `json:"rule,omitempty"` // rule that inferred the value without a provider call
+	EnrichedAt  time.Time   `json:"enriched_at"`
+}
+
+// Candidate is one of the values a provider returned for a field
+type Candidate struct {
+	Value       string  `json:"value"`
+	Probability float64 `json:"probability"`
+}
+
+// EnrichmentAudit records a field value replaced by a background job
+type EnrichmentAudit struct {
+	PersonID  int       `json:"person_id"`
+	Field     string    `json:"field"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+	Provider  string    `json:"provider"`
+	Job       string    `json:"job"`
+	ChangedAt time.Time `json:"changed_at"`
+}
@@ -0,0 +1,60 @@
+package entities
+
+import "github.com/agl/fio/internal/domain/query"
+
+// Filter operators, written as field[op]=value in a request
+const (
+	OpEq   = "eq"
+	OpNe   = "ne"
+	OpGt   = "gt"
+	OpGte  = "gte"
+	OpLt   = "lt"
+	OpLte  = "lte"
+	OpIn   = "in"
+	OpNull = "null"
+)
+
+// FilterParams holds raw filter values keyed by field and operator
+type FilterParams map[string]map[string]string
+
+// FilterFields lists the person fields a filter can match
+var FilterFields = []string{"name", "surname", "patronymic", FieldAge, FieldGender, FieldNationality}
+
+// StringFilter matches a text field. Unset operators do not filter, Null
+// selects people with (true) or without (false) a missing value.
+type StringFilter struct {
+	Eq   *string
+	Ne   *string
+	In   []string
+	Null *bool
+}
+
+// IntFilter matches a numeric field, see StringFilter
+type IntFilter struct {
+	Eq   *int
+	Ne   *int
+	Gt   *int
+	Gte  *int
+	Lt   *int
+	Lte  *int
+	In   []int
+	Null *bool
+}
+
+// PersonFilter selects people matching every set condition
+type PersonFilter struct {
+	Name        StringFilter
+	Surname     StringFilter
+	Patronymic  StringFilter
+	Age         IntFilter
+	Gender      StringFilter
+	Nationality StringFilter
+
+	// Query is a parsed q expression, nil when absent
+	Query query.Node
+
+	// MinConfidence excludes people with an enriched field of lower probability
+	MinConfidence float64
+	// Translit matches names by their Latin spellings in any script
+	Translit bool
+}
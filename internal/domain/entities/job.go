@@ -0,0 +1,19 @@
+package entities
+
+import "time"
+
+// JobStatus is the progress of a background job, counters describe the current or last run
+// @Description State and progress of a background job
+type JobStatus struct {
+	Name           string     `json:"name"`
+	Interval       string     `json:"interval"`
+	Running        bool       `json:"running"`
+	Runs           int        `json:"runs"`
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	Total          int64      `json:"total"`
+	Processed      int64      `json:"processed"`
+	Updated        int64      `json:"updated"`
+	Failed         int64      `json:"failed"`
+}
@@ -1,20 +1,45 @@
 package entities
 
+// Fields filled by enrichment providers
+const (
+	FieldAge         = "age"
+	FieldGender      = "gender"
+	FieldNationality = "nationality"
+)
+
+// Genders a person can have
+const (
+	GenderMale   = "male"
+	GenderFemale = "female"
+)
+
+// EnrichedFields lists every field filled by enrichment providers
+var EnrichedFields = []string{FieldAge, FieldGender, FieldNationality}
+
 // Person represents a person entity
 // @Description Person information with age, gender and nationality
 type Person struct {
-	Name        string  `json:"name"`
-	Surname     string  `json:"surname"`
-	Patronymic  *string `json:"patronymic,omitempty"`
-	Age         int     `json:"age"`
-	Gender      string  `json:"gender"`
-	Nationality string  `json:"nationality"`
+	ID          int      `json:"id,omitempty"`
+	Name        string   `json:"name"`
+	Surname     string   `json:"surname"`
+	Patronymic  *string  `json:"patronymic,omitempty"`
+	Age         int      `json:"age"`
+	Gender      string   `json:"gender"`
+	Nationality string   `json:"nationality"`
+	Pending     []string `json:"pending,omitempty"` // fields still waiting for enrichment
+	Locked      []string `json:"locked,omitempty"`  // fields enrichment must not overwrite
+
+	Enrichment map[string]Provenance `json:"enrichment,omitempty"` // keyed by field
+
+	CountryHint string `json:"-"` // country passed to age and gender providers
 }
 
 type Nationalities struct {
+	Count     int           `json:"count"`
 	Countries []Nationality `json:"country"`
 }
 
 type Nationality struct {
-	CountryID string `json:"country_id"`
+	CountryID   string  `json:"country_id"`
+	Probability float64 `json:"probability"`
 }
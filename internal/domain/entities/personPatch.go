@@ -0,0 +1,28 @@
+package entities
+
+// PersonPatch is a partial update of a person sent by a client. Omitted
+// fields are left unchanged. Provenance and pending fields are not part of
+// it, only enrichment writes them.
+// @Description Fields of a person to update
+type PersonPatch struct {
+	Name        string   `json:"name,omitempty"`
+	Surname     string   `json:"surname,omitempty"`
+	Patronymic  *string  `json:"patronymic,omitempty"`
+	Age         int      `json:"age,omitempty"`
+	Gender      string   `json:"gender,omitempty"`
+	Nationality string   `json:"nationality,omitempty"`
+	Locked      []string `json:"locked,omitempty"` // fields enrichment must not overwrite
+}
+
+// Person returns the update as a person without provenance
+func (p PersonPatch) Person() Person {
+	return Person{
+		Name:        p.Name,
+		Surname:     p.Surname,
+		Patronymic:  p.Patronymic,
+		Age:         p.Age,
+		Gender:      p.Gender,
+		Nationality: p.Nationality,
+		Locked:      p.Locked,
+	}
+}
@@ -6,4 +6,5 @@ type ReceivedPerson struct {
 	Name        string  `json:"name"`
 	Surname     string  `json:"surname"`
 	Patronymic  *string `json:"patronymic,omitempty"`
+	CountryID   *string `json:"country_id,omitempty"` // ISO 3166-1 alpha-2 hint for age and gender prediction
 }
\ No newline at end of file
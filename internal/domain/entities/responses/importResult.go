@@ -0,0 +1,9 @@
+package responses
+
+// ImportResult is the outcome of one person of a bulk import
+type ImportResult struct {
+	Index   int      `json:"index"`
+	ID      int      `json:"id,omitempty"`
+	Pending []string `json:"pending,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
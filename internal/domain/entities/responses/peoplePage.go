@@ -0,0 +1,10 @@
+package responses
+
+import "github.com/agl/fio/internal/domain/entities"
+
+// PeoplePage is one page of a people list
+type PeoplePage struct {
+	Message    string            `json:"message"`
+	Data       []entities.Person `json:"data"`
+	NextCursor string            `json:"next_cursor,omitempty"` // pass as after to get the next page, absent on the last page
+}
@@ -0,0 +1,18 @@
+package responses
+
+// Problem describes a failed request as defined by RFC 7807
+// @Description Problem details of a failed request (RFC 7807), sent as application/problem+json
+type Problem struct {
+	Type     string       `json:"type"`               // URI reference identifying the problem type
+	Title    string       `json:"title"`              // short summary, the same for every problem of a type
+	Status   int          `json:"status"`             // HTTP status code
+	Detail   string       `json:"detail,omitempty"`   // explanation of this occurrence
+	Instance string       `json:"instance,omitempty"` // request URI the problem occurred at
+	Errors   []FieldError `json:"errors,omitempty"`   // set for validation failures
+}
+
+// FieldError is a validation failure of one input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,9 @@
+package entities
+
+// SearchHit is a person found by a search with its relevance
+// @Description Person matching a search query, ranked by score
+type SearchHit struct {
+	Person
+	Score     float64 `json:"score"`               // trigram similarity (0 to 1) or full-text rank
+	Highlight string  `json:"highlight,omitempty"` // full name with matches wrapped in <mark>, full-text only
+}
@@ -0,0 +1,10 @@
+package entities
+
+// SortKey orders a list by a field, descending when Desc is set
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// SortFields lists the person fields a list can be sorted by
+var SortFields = []string{"id", "name", "surname", "patronymic", FieldAge, FieldGender, FieldNationality}
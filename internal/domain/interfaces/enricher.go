@@ -0,0 +1,26 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/agl/fio/internal/domain/entities"
+)
+
+// Enricher fills the derived characteristics of a person (age, gender,
+// nationality) from its name.
+type Enricher interface {
+	Enrich(ctx context.Context, person *entities.Person) error
+}
+
+// Provider is a single enrichment source responsible for one field.
+type Provider interface {
+	Enricher
+	Name() string
+	Field() string
+}
+
+// BatchEnricher enriches many people with as few upstream calls as possible.
+// The returned errors are aligned with people, nil for every success.
+type BatchEnricher interface {
+	EnrichBatch(ctx context.Context, people []*entities.Person) []error
+}
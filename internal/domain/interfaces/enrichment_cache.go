@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/agl/fio/internal/domain/entities"
+)
+
+// EnrichmentCacheStore persists enrichment results of a provider keyed by normalized first name
+type EnrichmentCacheStore interface {
+	Get(ctx context.Context, provider, name string) (entities.Person, bool, error)
+	Set(ctx context.Context, provider, name string, person entities.Person, ttl time.Duration) error
+	Purge(ctx context.Context, name string) error
+}
@@ -0,0 +1,9 @@
+package interfaces
+
+import "github.com/agl/fio/internal/domain/entities"
+
+// JobRunner exposes the background jobs of the service to operators.
+type JobRunner interface {
+	Statuses() []entities.JobStatus
+	Trigger(name string) error
+}
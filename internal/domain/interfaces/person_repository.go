@@ -1,13 +1,25 @@
 package interfaces
 
 import (
+	"context"
+	"time"
+
 	"github.com/agl/fio/internal/domain/entities"
 )
 
 type PersonRepository interface {
-	DeletePersonByID(id int) error
-	GetPersonByID(id int) (entities.Person, error)
-	GetPeopleByFilter(filter entities.Person, page, limit string) ([]entities.Person, error)
-	CreatePerson(person entities.Person) (int, error)
-	UpdatePersonByID(id int, p entities.Person) error
+	DeletePersonByID(ctx context.Context, id int) error
+	GetPersonByID(ctx context.Context, id int) (entities.Person, error)
+	GetPeopleByFilter(ctx context.Context, filter entities.PersonFilter, sort []entities.SortKey, after *entities.Cursor, limit int) ([]entities.Person, *entities.Cursor, error)
+	SearchPeople(ctx context.Context, query string, threshold float64, limit int) ([]entities.SearchHit, error)
+	FullTextSearchPeople(ctx context.Context, words []string, limit int) ([]entities.SearchHit, error)
+	CreatePerson(ctx context.Context, person entities.Person) (int, error)
+	UpdatePersonByID(ctx context.Context, id int, p entities.Person) error
+	UpdateEnrichedPerson(ctx context.Context, id int, p entities.Person) ([]string, error)
+	GetPendingEnrichment(ctx context.Context, limit, maxAttempts int) ([]entities.Person, error)
+	RecordEnrichmentAttempt(ctx context.Context, id int) error
+	GetStaleEnrichment(ctx context.Context, enrichedBefore time.Time, minConfidence float64, checkedBefore time.Time, afterID, limit int) ([]entities.Person, error)
+	MarkEnrichmentChecked(ctx context.Context, ids []int) error
+	RecordEnrichmentAudit(ctx context.Context, audits []entities.EnrichmentAudit) error
+	SetFieldLock(ctx context.Context, id int, field string, locked bool) ([]string, error)
 }
@@ -1,11 +1,18 @@
 package interfaces
 
-import "github.com/agl/fio/internal/domain/entities"
+import (
+	"context"
+
+	"github.com/agl/fio/internal/domain/entities"
+)
 
 type PersonService interface {
-	DeletePersonByID(idStr string) error
-	GetPersonByID(idStr string) (entities.Person, error)
-	GetPeopleByFilter(name, surname, ageStr, gender, nationality, page, limit string, patronymic *string) ([]entities.Person, error)
-	CreatePerson(person entities.Person) (int, error)
-	UpdatePersonByID(person entities.Person, idStr string) error
+	DeletePersonByID(ctx context.Context, idStr string) error
+	GetPersonByID(ctx context.Context, idStr string) (entities.Person, error)
+	GetPeopleByFilter(ctx context.Context, params entities.FilterParams, q, minConfidenceStr, translitStr, sortStr, after, limitStr string) ([]entities.Person, string, error)
+	SearchPeople(ctx context.Context, q, mode, thresholdStr, limitStr string) ([]entities.SearchHit, error)
+	ValidateReceivedPerson(ctx context.Context, person entities.ReceivedPerson) (entities.ReceivedPerson, error)
+	CreatePerson(ctx context.Context, person entities.Person) (int, error)
+	UpdatePersonByID(ctx context.Context, patch entities.PersonPatch, idStr string) error
+	SetFieldLock(ctx context.Context, idStr, field string, locked bool) ([]string, error)
 }
@@ -0,0 +1,75 @@
+// Package names normalizes person names for storage and search.
+package names
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize returns the display form of a name: NFC, trimmed, single
+// spaces, and every part of a compound name title-cased. Parts written in
+// mixed case on purpose (McDonald, DiCaprio) keep their inner capitals.
+func Normalize(name string) string {
+	name = strings.Join(strings.Fields(norm.NFC.String(name)), " ")
+
+	var b strings.Builder
+	b.Grow(len(name))
+
+	part := make([]rune, 0, len(name))
+	flush := func() {
+		b.WriteString(titleCase(part))
+		part = part[:0]
+	}
+
+	for _, r := range name {
+		if isSeparator(r) {
+			flush()
+			b.WriteRune(r)
+
+			continue
+		}
+
+		part = append(part, r)
+	}
+	flush()
+
+	return b.String()
+}
+
+// Key returns the search key of a name: its normalized form lowercased,
+// with ё folded into е so both spellings match
+func Key(name string) string {
+	key := strings.ToLower(Normalize(name))
+
+	return strings.ReplaceAll(key, "ё", "е")
+}
+
+func isSeparator(r rune) bool {
+	return r == ' ' || r == '-' || r == '\'' || r == '’'
+}
+
+func titleCase(part []rune) string {
+	if len(part) == 0 {
+		return ""
+	}
+
+	var upper, lower bool
+	for _, r := range part[1:] {
+		upper = upper || unicode.IsUpper(r)
+		lower = lower || unicode.IsLower(r)
+	}
+
+	out := make([]rune, len(part))
+	out[0] = unicode.ToUpper(part[0])
+	for i, r := range part[1:] {
+		if upper && lower {
+			out[i+1] = r
+		} else {
+			out[i+1] = unicode.ToLower(r)
+		}
+	}
+
+	return string(out)
+}
@@ -0,0 +1,76 @@
+package names
+
+import (
+	"slices"
+	"strings"
+)
+
+// Scheme is a Cyrillic to Latin transliteration table of lowercase letters
+type Scheme map[rune]string
+
+// GOST is GOST 7.79-2000 system B. The hard and soft signs are dropped and
+// the marks of ы and э omitted, since nobody types them in a search box.
+var GOST = Scheme{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "j", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "x", 'ц': "cz", 'ч': "ch", 'ш': "sh", 'щ': "shh", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+}
+
+// ICAO is the ICAO Doc 9303 table used in machine readable passports
+var ICAO = Scheme{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "ie",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "iu", 'я': "ia",
+	'і': "i", 'ї': "i", 'є': "ie", 'ґ': "g",
+}
+
+// Schemes are the transliterations stored for cross-script search
+var Schemes = []Scheme{GOST, ICAO}
+
+// Transliterate returns the lowercase Latin spelling of name under scheme,
+// characters outside the scheme are kept as they are
+func Transliterate(name string, scheme Scheme) string {
+	runes := []rune(strings.ToLower(Normalize(name)))
+
+	var b strings.Builder
+	for i, r := range runes {
+		latin, ok := scheme[r]
+		if !ok {
+			b.WriteRune(r)
+
+			continue
+		}
+
+		// GOST writes ц as c before и, е, ы and й
+		if r == 'ц' && scheme['ц'] == "cz" && i+1 < len(runes) && strings.ContainsRune("иеый", runes[i+1]) {
+			latin = "c"
+		}
+
+		b.WriteString(latin)
+	}
+
+	return b.String()
+}
+
+// LatinKeys returns the distinct Latin spellings of name under every
+// scheme. A name already written in Latin has a single key.
+func LatinKeys(name string) []string {
+	if name == "" {
+		return []string{}
+	}
+
+	keys := make([]string, 0, len(Schemes))
+	for _, scheme := range Schemes {
+		key := Transliterate(name, scheme)
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
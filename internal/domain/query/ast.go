@@ -0,0 +1,95 @@
+// Package query parses the compact people search language, for example
+//
+//	age>=30 AND (nationality:RU OR nationality:KZ) AND surname~"ov"
+//
+// A query compares fields with values and combines the comparisons with
+// AND, OR, NOT and parentheses; NOT binds tightest and OR loosest. Values
+// are bare words, numbers or double quoted strings, and the bare word null
+// stands for a missing value.
+package query
+
+// Node is an expression of a query: *And, *Or, *Not or *Comparison
+type Node interface {
+	node()
+}
+
+type And struct {
+	Left, Right Node
+}
+
+type Or struct {
+	Left, Right Node
+}
+
+type Not struct {
+	Expr Node
+}
+
+// Op is a comparison operator
+type Op string
+
+const (
+	Eq       Op = ":" // also written =
+	Ne       Op = "!="
+	Gt       Op = ">"
+	Gte      Op = ">="
+	Lt       Op = "<"
+	Lte      Op = "<="
+	Contains Op = "~" // case insensitive substring
+)
+
+// Comparison compares a field with a value. Value is a string for text
+// fields, an int for numeric ones and nil for null.
+type Comparison struct {
+	Field string
+	Op    Op
+	Value any
+	Pos   int // of the value, in characters from 1
+}
+
+func (*And) node()        {}
+func (*Or) node()         {}
+func (*Not) node()        {}
+func (*Comparison) node() {}
+
+type fieldKind int
+
+const (
+	textField fieldKind = iota
+	nameField
+	numericField
+)
+
+// Fields lists the fields a query can compare
+var Fields = []string{"name", "surname", "patronymic", "age", "gender", "nationality"}
+
+var fieldKinds = map[string]fieldKind{
+	"name":        nameField,
+	"surname":     nameField,
+	"patronymic":  nameField,
+	"age":         numericField,
+	"gender":      textField,
+	"nationality": textField,
+}
+
+var kindOps = map[fieldKind][]Op{
+	textField:    {Eq, Ne},
+	nameField:    {Eq, Ne, Contains},
+	numericField: {Eq, Ne, Gt, Gte, Lt, Lte},
+}
+
+// Walk calls fn for every comparison of node, left to right
+func Walk(node Node, fn func(*Comparison)) {
+	switch n := node.(type) {
+	case *And:
+		Walk(n.Left, fn)
+		Walk(n.Right, fn)
+	case *Or:
+		Walk(n.Left, fn)
+		Walk(n.Right, fn)
+	case *Not:
+		Walk(n.Expr, fn)
+	case *Comparison:
+		fn(n)
+	}
+}
@@ -0,0 +1,189 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxLength = 1000 // characters
+	MaxDepth  = 20   // nested parentheses and NOTs
+)
+
+// Parse parses a query into its syntax tree. Comparisons are checked against
+// the known fields, their operators and value types, so a tree returned
+// without an error can be compiled as is.
+func Parse(input string) (Node, error) {
+	if utf8.RuneCountInString(input) > MaxLength {
+		return nil, &Error{MaxLength + 1, fmt.Sprintf("query is longer than %d characters", MaxLength)}
+	}
+
+	tokens, err := scan(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	node, err := p.or(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "expected AND, OR or end of query, got %s", t)
+	}
+
+	return node, nil
+}
+
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &Error{t.pos, fmt.Sprintf(format, args...)}
+}
+
+// or = and { OR and }
+func (p *parser) or(depth int) (Node, error) {
+	left, err := p.and(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().keyword("OR") {
+		p.advance()
+
+		right, err := p.and(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{left, right}
+	}
+
+	return left, nil
+}
+
+// and = unary { AND unary }
+func (p *parser) and(depth int) (Node, error) {
+	left, err := p.unary(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().keyword("AND") {
+		p.advance()
+
+		right, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &And{left, right}
+	}
+
+	return left, nil
+}
+
+// unary = NOT unary | "(" or ")" | comparison
+func (p *parser) unary(depth int) (Node, error) {
+	t := p.peek()
+
+	if depth >= MaxDepth && (t.keyword("NOT") || t.kind == tokenLParen) {
+		return nil, p.errorf(t, "query is nested deeper than %d levels", MaxDepth)
+	}
+
+	switch {
+	case t.keyword("NOT"):
+		p.advance()
+
+		expr, err := p.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Not{expr}, nil
+	case t.kind == tokenLParen:
+		p.advance()
+
+		expr, err := p.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.advance(); closing.kind != tokenRParen {
+			return nil, p.errorf(closing, "expected \")\" to close \"(\" at position %d, got %s", t.pos, closing)
+		}
+
+		return expr, nil
+	default:
+		return p.comparison()
+	}
+}
+
+// comparison = field op value
+func (p *parser) comparison() (Node, error) {
+	t := p.advance()
+	if t.kind != tokenWord || t.keyword("AND") || t.keyword("OR") {
+		return nil, p.errorf(t, "expected a field, got %s", t)
+	}
+
+	field := strings.ToLower(t.text)
+	kind, ok := fieldKinds[field]
+	if !ok {
+		return nil, p.errorf(t, "unknown field %q, expected one of %s", t.text, strings.Join(Fields, ", "))
+	}
+
+	opToken := p.advance()
+	if opToken.kind != tokenOp {
+		return nil, p.errorf(opToken, "expected an operator after %s, got %s", field, opToken)
+	}
+
+	op := Op(opToken.text)
+	if op == "=" {
+		op = Eq
+	}
+	if !slices.Contains(kindOps[kind], op) {
+		return nil, p.errorf(opToken, "%s does not support %q", field, opToken.text)
+	}
+
+	v := p.advance()
+	switch {
+	case v.kind != tokenWord && v.kind != tokenString:
+		return nil, p.errorf(v, "expected a value after %q, got %s", opToken.text, v)
+	case v.keyword("null"):
+		if op != Eq && op != Ne {
+			return nil, p.errorf(v, "null can only be compared with \":\" or \"!=\"")
+		}
+
+		return &Comparison{field, op, nil, v.pos}, nil
+	case kind == numericField:
+		n, err := strconv.Atoi(v.text)
+		if err != nil {
+			return nil, p.errorf(v, "%s must be compared with an integer, got %s", field, v)
+		}
+
+		return &Comparison{field, op, n, v.pos}, nil
+	case v.text == "":
+		return nil, p.errorf(v, "expected a non-empty value")
+	default:
+		return &Comparison{field, op, v.text, v.pos}, nil
+	}
+}
@@ -0,0 +1,170 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// render prints a tree with every operation in parentheses
+func render(node Node) string {
+	switch n := node.(type) {
+	case *And:
+		return "(" + render(n.Left) + " AND " + render(n.Right) + ")"
+	case *Or:
+		return "(" + render(n.Left) + " OR " + render(n.Right) + ")"
+	case *Not:
+		return "NOT " + render(n.Expr)
+	case *Comparison:
+		if n.Value == nil {
+			return n.Field + string(n.Op) + "null"
+		}
+		if s, ok := n.Value.(string); ok {
+			return fmt.Sprintf("%s%s%q", n.Field, n.Op, s)
+		}
+		return fmt.Sprintf("%s%s%v", n.Field, n.Op, n.Value)
+	default:
+		return fmt.Sprintf("%T", node)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"comparison", `age>=30`, `age>=30`},
+		{"equals alias", `nationality=RU`, `nationality:"RU"`},
+		{"all operators", `age:1 AND age!=2 AND age>3 AND age>=4 AND age<5 AND age<=6`,
+			`(((((age:1 AND age!=2) AND age>3) AND age>=4) AND age<5) AND age<=6)`},
+		{"and binds tighter than or", `age:1 OR age:2 AND age:3`, `(age:1 OR (age:2 AND age:3))`},
+		{"or is left associative", `age:1 OR age:2 OR age:3`, `((age:1 OR age:2) OR age:3)`},
+		{"not binds tightest", `NOT age:1 AND age:2`, `(NOT age:1 AND age:2)`},
+		{"double not", `NOT NOT age:1`, `NOT NOT age:1`},
+		{"parentheses", `age>=30 AND (nationality:RU OR nationality:KZ) AND surname~"ov"`,
+			`((age>=30 AND (nationality:"RU" OR nationality:"KZ")) AND surname~"ov")`},
+		{"not of a group", `NOT (age:1 OR age:2)`, `NOT (age:1 OR age:2)`},
+		{"case insensitive keywords and fields", `Age:1 and NAME:Ivan or not gender:male`,
+			`((age:1 AND name:"Ivan") OR NOT gender:"male")`},
+		{"spaces around operators", `  age  >=  30  `, `age>=30`},
+		{"null", `patronymic:null AND gender!=NULL`, `(patronymic:null AND gender!=null)`},
+		{"quoted null is a string", `name:"null"`, `name:"null"`},
+		{"quoted keyword is a value", `surname:"AND"`, `surname:"AND"`},
+		{"bare keyword after operator is a value", `name:or`, `name:"or"`},
+		{"quoted spaces", `surname:"Ван Дер Берг"`, `surname:"Ван Дер Берг"`},
+		{"escaped quote", `surname:"O\"Brien"`, `surname:"O\"Brien"`},
+		{"escaped backslash", `surname~"a\\b"`, `surname~"a\\b"`},
+		{"hyphen and apostrophe in a word", `surname:Saltykov-Shchedrin AND name:D'Artagnan`,
+			`(surname:"Saltykov-Shchedrin" AND name:"D'Artagnan")`},
+		{"quoted number", `age:"30"`, `age:30`},
+		{"negative number", `age>-1`, `age>-1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.input, err)
+			}
+
+			if got := render(node); got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		pos     int
+		message string
+	}{
+		{``, 1, `expected a field, got end of query`},
+		{`   `, 4, `expected a field, got end of query`},
+		{`AND`, 1, `expected a field, got "AND"`},
+		{`foo:1`, 1, `unknown field "foo", expected one of name, surname, patronymic, age, gender, nationality`},
+		{`age`, 4, `expected an operator after age, got end of query`},
+		{`age 30`, 5, `expected an operator after age, got "30"`},
+		{`age:`, 5, `expected a value after ":", got end of query`},
+		{`age:(`, 5, `expected a value after ":", got "("`},
+		{`age>=x`, 6, `age must be compared with an integer, got "x"`},
+		{`age:"3 0"`, 5, `age must be compared with an integer, got string "3 0"`},
+		{`age~3`, 4, `age does not support "~"`},
+		{`gender>male`, 7, `gender does not support ">"`},
+		{`age<null`, 5, `null can only be compared with ":" or "!="`},
+		{`name:""`, 6, `expected a non-empty value`},
+		{`name:"Ivan`, 6, `unterminated string`},
+		{`age ! 3`, 5, `expected "=" after "!"`},
+		{`age:1 & age:2`, 7, `unexpected character '&'`},
+		{`(age:1`, 7, `expected ")" to close "(" at position 1, got end of query`},
+		{`age:1)`, 6, `expected AND, OR or end of query, got ")"`},
+		{`age:1 name:x`, 7, `expected AND, OR or end of query, got "name"`},
+		{`age:1 AND`, 10, `expected a field, got end of query`},
+		{`age:1 OR OR age:2`, 10, `expected a field, got "OR"`},
+		{`NOT`, 4, `expected a field, got end of query`},
+		{`имя:Иван AND возраст:3`, 1, `unknown field "имя", expected one of name, surname, patronymic, age, gender, nationality`},
+		{`name:Иван AND возраст:3`, 15, `unknown field "возраст", expected one of name, surname, patronymic, age, gender, nationality`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+
+			var syntaxErr *Error
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse(%q) error = %v, want *Error", tt.input, err)
+			}
+
+			if syntaxErr.Pos != tt.pos || syntaxErr.Message != tt.message {
+				t.Errorf("Parse(%q) error = %d %q, want %d %q", tt.input, syntaxErr.Pos, syntaxErr.Message, tt.pos, tt.message)
+			}
+		})
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	deep := ""
+	for range MaxDepth {
+		deep += "("
+	}
+	deep += "age:1"
+	for range MaxDepth {
+		deep += ")"
+	}
+	if _, err := Parse(deep); err != nil {
+		t.Errorf("Parse at depth %d error: %v", MaxDepth, err)
+	}
+
+	var syntaxErr *Error
+	_, err := Parse("(" + deep + ")")
+	if !errors.As(err, &syntaxErr) || syntaxErr.Pos != MaxDepth+1 {
+		t.Errorf("Parse at depth %d error = %v, want an error at position %d", MaxDepth+1, err, MaxDepth+1)
+	}
+
+	long := "name:x"
+	for len(long) <= MaxLength {
+		long += " OR name:x"
+	}
+	_, err = Parse(long)
+	if !errors.As(err, &syntaxErr) || syntaxErr.Pos != MaxLength+1 {
+		t.Errorf("Parse of %d characters error = %v, want an error at position %d", len(long), err, MaxLength+1)
+	}
+}
+
+func TestComparisonPosition(t *testing.T) {
+	node, err := Parse(`name:Иван AND age >= 30`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var positions []int
+	Walk(node, func(cmp *Comparison) {
+		positions = append(positions, cmp.Pos)
+	})
+
+	if fmt.Sprint(positions) != "[6 22]" {
+		t.Errorf("value positions = %v, want [6 22]", positions)
+	}
+}
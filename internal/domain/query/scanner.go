@@ -0,0 +1,112 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // in characters, from 1
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return fmt.Sprintf("string %q", t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// keyword reports whether t is the bare word kw, in any case
+func (t token) keyword(kw string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, kw)
+}
+
+// Error is a syntax error of a query
+type Error struct {
+	Pos     int // in characters, from 1
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Pos)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '\'' || r == '.'
+}
+
+// scan splits input into tokens ending with tokenEOF
+func scan(input string) ([]token, error) {
+	runes := []rune(input)
+
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "(", start + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")", start + 1})
+			i++
+		case r == ':' || r == '=' || r == '~':
+			tokens = append(tokens, token{tokenOp, string(r), start + 1})
+			i++
+		case r == '<' || r == '>' || r == '!':
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				i++
+			} else if r == '!' {
+				return nil, &Error{start + 1, `expected "=" after "!"`}
+			}
+			tokens = append(tokens, token{tokenOp, string(runes[start:i]), start + 1})
+		case r == '"':
+			var text strings.Builder
+			for i++; ; i++ {
+				if i == len(runes) {
+					return nil, &Error{start + 1, "unterminated string"}
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				} else if runes[i] == '"' {
+					break
+				}
+				text.WriteRune(runes[i])
+			}
+			i++
+			tokens = append(tokens, token{tokenString, text.String(), start + 1})
+		case isWordRune(r):
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenWord, string(runes[start:i]), start + 1})
+		default:
+			return nil, &Error{start + 1, fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+
+	return append(tokens, token{tokenEOF, "", len(runes) + 1}), nil
+}
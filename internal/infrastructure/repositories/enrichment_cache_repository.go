@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/agl/fio/internal/domain/entities"
+	. "github.com/agl/fio/pkg/logger"
+)
+
+// EnrichmentCacheRepository keeps enrichment results in the name_enrichment_cache table
+type EnrichmentCacheRepository struct {
+	db *sql.DB
+}
+
+func NewEnrichmentCacheRepository(db *sql.DB) *EnrichmentCacheRepository {
+	return &EnrichmentCacheRepository{db: db}
+}
+
+func (r *EnrichmentCacheRepository) Get(ctx context.Context, provider, name string) (entities.Person, bool, error) {
+	var payload []byte
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT payload FROM name_enrichment_cache
+		WHERE name = $1 AND provider = $2 AND expires_at > now()
+	`, name, provider).Scan(&payload)
+
+	if err == sql.ErrNoRows {
+		return entities.Person{}, false, nil
+	}
+	if err != nil {
+		Log.Info("Failed to get cached enrichment", "provider", provider, "name", name, "error", err)
+
+		return entities.Person{}, false, fmt.Errorf("failed to get cached enrichment: %w", err)
+	}
+
+	var person entities.Person
+	if err := json.Unmarshal(payload, &person); err != nil {
+		Log.Info("Failed to unmarshal cached enrichment", "provider", provider, "name", name, "error", err)
+
+		return entities.Person{}, false, fmt.Errorf("failed to unmarshal cached enrichment: %w", err)
+	}
+
+	return person, true, nil
+}
+
+func (r *EnrichmentCacheRepository) Set(ctx context.Context, provider, name string, person entities.Person, ttl time.Duration) error {
+	payload, err := json.Marshal(person)
+	if err != nil {
+		return fmt.Errorf("failed to marshal enrichment: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO name_enrichment_cache (name, provider, payload, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name, provider) DO UPDATE
+		SET payload = EXCLUDED.payload, expires_at = EXCLUDED.expires_at
+	`, name, provider, payload, time.Now().Add(ttl))
+
+	if err != nil {
+		Log.Info("Failed to store cached enrichment", "provider", provider, "name", name, "error", err)
+
+		return fmt.Errorf("failed to store cached enrichment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *EnrichmentCacheRepository) Purge(ctx context.Context, name string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM name_enrichment_cache WHERE name = $1`, name)
+	if err != nil {
+		Log.Info("Failed to purge cached enrichment", "name", name, "error", err)
+
+		return fmt.Errorf("failed to purge cached enrichment: %w", err)
+	}
+
+	Log.Debug("Cached enrichment purged", "name", name)
+
+	return nil
+}
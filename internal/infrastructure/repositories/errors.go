@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/agl/fio/internal/domain/apperrors"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// dbError converts a database failure into a domain error. Failures without
+// a domain meaning are wrapped with message and reported as internal.
+func dbError(message string, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return apperrors.Wrap(apperrors.ErrNotFound, "Person not found", err)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505": // unique_violation
+			return apperrors.Conflict("Person conflicts with an existing record", err)
+		case "22001": // string_data_right_truncation
+			return apperrors.Wrap(apperrors.ErrValidation, "Value is too long", err)
+		case "22P02", "2201W", "2201X": // invalid_text_representation, invalid limit or offset
+			return apperrors.Wrap(apperrors.ErrValidation, "Invalid query parameter", err)
+		}
+	}
+
+	return fmt.Errorf("%s: %w", message, err)
+}
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/names"
+)
+
+// conditions accumulates the WHERE clauses of a query and their arguments
+type conditions struct {
+	clauses []string
+	args    []any
+}
+
+// arg adds an argument and returns its placeholder
+func (c *conditions) arg(value any) string {
+	c.args = append(c.args, value)
+
+	return fmt.Sprintf("$%d", len(c.args))
+}
+
+// add appends a clause whose %s is replaced by the placeholder of arg
+func (c *conditions) add(clause string, arg any) {
+	c.clauses = append(c.clauses, fmt.Sprintf(clause, c.arg(arg)))
+}
+
+func (c *conditions) where() string {
+	if len(c.clauses) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(c.clauses, " AND ")
+}
+
+// addNull matches a missing value with null set and a present one otherwise
+func (c *conditions) addNull(column string, null *bool) {
+	switch {
+	case null == nil:
+	case *null:
+		c.clauses = append(c.clauses, column+" IS NULL")
+	default:
+		c.clauses = append(c.clauses, column+" IS NOT NULL")
+	}
+}
+
+// addText compares expr with the values mapped by key. A person without a
+// value is not equal to anything, so ne keeps them.
+func (c *conditions) addText(expr, column string, f entities.StringFilter, key func(string) string) {
+	if f.Eq != nil {
+		c.add(expr+" = %s", key(*f.Eq))
+	}
+	if f.Ne != nil {
+		c.add(expr+" IS DISTINCT FROM %s", key(*f.Ne))
+	}
+	if f.In != nil {
+		keys := make([]string, len(f.In))
+		for i, v := range f.In {
+			keys[i] = key(v)
+		}
+		c.add(expr+" = ANY(%s)", keys)
+	}
+	c.addNull(column, f.Null)
+}
+
+// addLatin matches a name by any of its Latin spellings
+func (c *conditions) addLatin(column string, f entities.StringFilter) {
+	latin := "p." + column + "_latin"
+
+	if f.Eq != nil {
+		c.add(latin+" && %s", names.LatinKeys(*f.Eq))
+	}
+	if f.Ne != nil {
+		c.add("NOT COALESCE("+latin+" && %s, false)", names.LatinKeys(*f.Ne))
+	}
+	if f.In != nil {
+		var keys []string
+		for _, v := range f.In {
+			keys = append(keys, names.LatinKeys(v)...)
+		}
+		c.add(latin+" && %s", keys)
+	}
+	c.addNull("p."+column, f.Null)
+}
+
+func (c *conditions) addInt(column string, f entities.IntFilter) {
+	comparisons := []struct {
+		operator string
+		value    *int
+	}{
+		{"=", f.Eq}, {"IS DISTINCT FROM", f.Ne},
+		{">", f.Gt}, {">=", f.Gte}, {"<", f.Lt}, {"<=", f.Lte},
+	}
+	for _, cmp := range comparisons {
+		if cmp.value != nil {
+			c.add(column+" "+cmp.operator+" %s", *cmp.value)
+		}
+	}
+	if f.In != nil {
+		c.add(column+" = ANY(%s)", f.In)
+	}
+	c.addNull(column, f.Null)
+}
+
+// personConditions translates a filter over personColumns
+func personConditions(filter entities.PersonFilter) *conditions {
+	c := &conditions{}
+
+	nameFilters := []struct {
+		column string
+		filter entities.StringFilter
+	}{
+		{"name", filter.Name},
+		{"surname", filter.Surname},
+		{"patronymic", filter.Patronymic},
+	}
+	for _, f := range nameFilters {
+		if filter.Translit {
+			c.addLatin(f.column, f.filter)
+		} else {
+			c.addText("p."+f.column+"_key", "p."+f.column, f.filter, names.Key)
+		}
+	}
+
+	c.addInt("p.age", filter.Age)
+	c.addText("g.gender", "p.gender_id", filter.Gender, asIs)
+	c.addText("n.nationality", "p.nationality_id", filter.Nationality, asIs)
+
+	if filter.Query != nil {
+		c.clauses = append(c.clauses, c.compile(filter.Query))
+	}
+
+	if filter.MinConfidence > 0 {
+		c.addMinConfidence(filter.MinConfidence)
+	}
+
+	return c
+}
+
+// confidenceFields are the fields whose providers report a probability
+var confidenceFields = []string{entities.FieldGender, entities.FieldNationality}
+
+// addMinConfidence matches people whose every probability is at least min.
+// Gender and nationality need a provenance of their own, so a person whose
+// fields are still pending or were never enriched does not pass for a
+// confident one.
+func (c *conditions) addMinConfidence(min float64) {
+	placeholder := c.arg(min)
+	c.clauses = append(c.clauses,
+		"NOT EXISTS (SELECT 1 FROM person_enrichment e WHERE e.person_id = p.id AND e.probability < "+placeholder+")",
+		fmt.Sprintf("(SELECT count(*) FROM person_enrichment e WHERE e.person_id = p.id AND e.field = ANY(%s) AND e.probability >= %s) = %d",
+			c.arg(confidenceFields), placeholder, len(confidenceFields)),
+	)
+}
+
+func asIs(value string) string {
+	return value
+}
@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/agl/fio/internal/domain/entities"
+	. "github.com/agl/fio/pkg/logger"
+)
+
+// saveEnrichment upserts the provenance of every enriched field of a person
+func saveEnrichment(ctx context.Context, tx *sql.Tx, personID int, enrichment map[string]entities.Provenance) error {
+	for field, provenance := range enrichment {
+		candidates, err := json.Marshal(provenance.Candidates)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s candidates: %w", field, err)
+		}
+		if provenance.Candidates == nil {
+			candidates = []byte("[]")
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO person_enrichment (person_id, field, provider, probability, sample_count, candidates, country_hint, rule, enriched_at)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), $9)
+			ON CONFLICT (person_id, field) DO UPDATE
+			SET provider = EXCLUDED.provider,
+				probability = EXCLUDED.probability,
+				sample_count = EXCLUDED.sample_count,
+				candidates = EXCLUDED.candidates,
+				country_hint = EXCLUDED.country_hint,
+				rule = EXCLUDED.rule,
+				enriched_at = EXCLUDED.enriched_at
+		`, personID, field, provenance.Provider, provenance.Probability, provenance.Count, candidates, provenance.CountryHint, provenance.Rule, provenance.EnrichedAt)
+
+		if err != nil {
+			Log.Info("Failed to save enrichment provenance", "id", personID, "field", field, "error", err)
+
+			return dbError("failed to save "+field+" provenance", err)
+		}
+	}
+
+	return nil
+}
+
+func deleteEnrichment(ctx context.Context, tx *sql.Tx, personID int, fields []string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `DELETE FROM person_enrichment WHERE person_id = $1 AND field = ANY($2)`, personID, fields)
+	if err != nil {
+		Log.Info("Failed to delete enrichment provenance", "id", personID, "fields", fields, "error", err)
+
+		return dbError("failed to delete provenance", err)
+	}
+
+	return nil
+}
+
+func (r *PersonRepository) getEnrichment(ctx context.Context, personID int) (map[string]entities.Provenance, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT field, provider, probability, sample_count, candidates, COALESCE(country_hint, ''), COALESCE(rule, ''), enriched_at
+		FROM person_enrichment
+		WHERE person_id = $1
+	`, personID)
+	if err != nil {
+		Log.Info("Failed to query enrichment provenance", "id", personID, "error", err)
+
+		return nil, dbError("failed to query provenance", err)
+	}
+	defer rows.Close()
+
+	var enrichment map[string]entities.Provenance
+	for rows.Next() {
+		var (
+			field       string
+			provenance  entities.Provenance
+			probability sql.NullFloat64
+			count       sql.NullInt64
+			candidates  []byte
+		)
+
+		if err := rows.Scan(&field, &provenance.Provider, &probability, &count, &candidates, &provenance.CountryHint, &provenance.Rule, &provenance.EnrichedAt); err != nil {
+			Log.Info("Failed to scan enrichment provenance", "id", personID, "error", err)
+
+			return nil, err
+		}
+
+		if probability.Valid {
+			provenance.Probability = &probability.Float64
+		}
+		if count.Valid {
+			c := int(count.Int64)
+			provenance.Count = &c
+		}
+		if err := json.Unmarshal(candidates, &provenance.Candidates); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s candidates: %w", field, err)
+		}
+
+		if enrichment == nil {
+			enrichment = make(map[string]entities.Provenance)
+		}
+		enrichment[field] = provenance
+	}
+
+	return enrichment, rows.Err()
+}
@@ -1,11 +1,16 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/agl/fio/internal/domain/apperrors"
 	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/names"
 	. "github.com/agl/fio/pkg/logger"
 )
 
@@ -17,220 +22,343 @@ func NewPersonRepository(db *sql.DB) *PersonRepository {
 	return &PersonRepository{db: db}
 }
 
-func (r *PersonRepository) UpdatePersonByID(id int, p entities.Person) error {
-    tx, err := r.db.Begin()
+// UpdatePersonByID applies an edit made by a client, locked fields included
+func (r *PersonRepository) UpdatePersonByID(ctx context.Context, id int, p entities.Person) error {
+	_, err := r.updatePerson(ctx, id, p, false)
+
+	return err
+}
+
+// UpdateEnrichedPerson applies the result of an enrichment job. Fields locked
+// by the time the row is written are left alone and returned, a lock taken
+// while the providers were being queried is honoured as well.
+func (r *PersonRepository) UpdateEnrichedPerson(ctx context.Context, id int, p entities.Person) ([]string, error) {
+	return r.updatePerson(ctx, id, p, true)
+}
+
+// updatePerson writes p under a row lock. With respectLocks the enriched
+// fields locked at that moment are dropped from p and returned.
+func (r *PersonRepository) updatePerson(ctx context.Context, id int, p entities.Person, respectLocks bool) ([]string, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
         Log.Error("Failed to begin transaction", "error", err)
 
-        return fmt.Errorf("failed to start transaction: %w", err)
+        return nil, dbError("failed to start transaction", err)
     }
-    defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
+    defer tx.Rollback()
 
     var (
         currName, currSurname, currPatronymic sql.NullString
         currAge                           sql.NullInt64
-        currGender, currNationality      string
-        currGenderID, currNationalityID  int
+        currGender, currNationality      sql.NullString
+        currGenderID, currNationalityID  sql.NullInt64
+        currLocked                       textArray
     )
     query := `
         SELECT p.name, p.surname, p.patronymic, p.age,
                g.id, g.gender,
-               n.id, n.nationality
+               n.id, n.nationality,
+               to_json(p.locked_fields)
         FROM people p
-        JOIN genders g ON p.gender_id = g.id
-        JOIN nationalities n ON p.nationality_id = n.id
-        WHERE p.id = $1` 
-    if err := tx.QueryRow(query, id).Scan(
+        LEFT JOIN genders g ON p.gender_id = g.id
+        LEFT JOIN nationalities n ON p.nationality_id = n.id
+        WHERE p.id = $1
+        FOR UPDATE OF p`
+    if err := tx.QueryRowContext(ctx, query, id).Scan(
         &currName, &currSurname, &currPatronymic, &currAge,
         &currGenderID, &currGender,
         &currNationalityID, &currNationality,
+        &currLocked,
     ); err != nil {
         Log.Info("Failed to fetch current person data", "id", id, "error", err)
-        return fmt.Errorf("failed to fetch current person data: %w", err)
+        return nil, dbError("failed to fetch current person data", err)
+    }
+
+    var skipped []string
+    if respectLocks {
+        p, skipped = withoutLocked(p, currLocked)
+        if len(skipped) > 0 {
+            Log.Info("Skipping locked fields of enriched person", "id", id, "fields", skipped)
+        }
     }
 
     newGenderID := currGenderID
-    if p.Gender != "" && p.Gender != currGender {
-        var gid int
-        err = tx.QueryRow(`SELECT id FROM genders WHERE gender = $1`, p.Gender).Scan(&gid)
+    if p.Gender != "" && p.Gender != currGender.String {
+        var gid int64
+        err = tx.QueryRowContext(ctx, `SELECT id FROM genders WHERE gender = $1`, p.Gender).Scan(&gid)
         if err == sql.ErrNoRows {
-            err = tx.QueryRow(`INSERT INTO genders(gender) VALUES($1) RETURNING id`, p.Gender).Scan(&gid)
+            err = tx.QueryRowContext(ctx, `INSERT INTO genders(gender) VALUES($1) RETURNING id`, p.Gender).Scan(&gid)
             if err != nil {
                 Log.Info("Failed to insert new gender", "gender", p.Gender, "error", err)
-                return fmt.Errorf("failed to insert gender: %w", err)
+                return nil, dbError("failed to insert gender", err)
             }
         } else if err != nil {
             Log.Info("Failed to query gender existence", "gender", p.Gender, "error", err)
-            return fmt.Errorf("failed to check gender: %w", err)
+            return nil, dbError("failed to check gender", err)
         }
-        newGenderID = gid
+        newGenderID = sql.NullInt64{Int64: gid, Valid: true}
     }
 
     newNationalityID := currNationalityID
-    if p.Nationality != "" && p.Nationality != currNationality {
-        var nid int
-        err = tx.QueryRow(`SELECT id FROM nationalities WHERE nationality = $1`, p.Nationality).Scan(&nid)
+    if p.Nationality != "" && p.Nationality != currNationality.String {
+        var nid int64
+        err = tx.QueryRowContext(ctx, `SELECT id FROM nationalities WHERE nationality = $1`, p.Nationality).Scan(&nid)
         if err == sql.ErrNoRows {
-            err = tx.QueryRow(`INSERT INTO nationalities(nationality) VALUES($1) RETURNING id`, p.Nationality).Scan(&nid)
+            err = tx.QueryRowContext(ctx, `INSERT INTO nationalities(nationality) VALUES($1) RETURNING id`, p.Nationality).Scan(&nid)
             if err != nil {
                 Log.Info("Failed to insert new nationality", "nationality", p.Nationality, "error", err)
-                return fmt.Errorf("failed to insert nationality: %w", err)
+                return nil, dbError("failed to insert nationality", err)
             }
         } else if err != nil {
             Log.Info("Failed to query nationality existence", "nationality", p.Nationality, "error", err)
-            return fmt.Errorf("failed to check nationality: %w", err)
+            return nil, dbError("failed to check nationality", err)
         }
-        newNationalityID = nid
+        newNationalityID = sql.NullInt64{Int64: nid, Valid: true}
     }
 
     setClauses := []string{}
     args := []any{}
     argPos := 1
+    changed := []string{}
 
     if p.Name != "" && p.Name != currName.String {
-        setClauses = append(setClauses, fmt.Sprintf("name = $%d", argPos))
-        args = append(args, p.Name)
-        argPos++
+        setClauses = append(setClauses, fmt.Sprintf("name = $%d, name_key = $%d, name_latin = $%d", argPos, argPos+1, argPos+2))
+        args = append(args, p.Name, names.Key(p.Name), names.LatinKeys(p.Name))
+        argPos += 3
     }
     if p.Surname != "" && p.Surname != currSurname.String {
-        setClauses = append(setClauses, fmt.Sprintf("surname = $%d", argPos))
-        args = append(args, p.Surname)
-        argPos++
+        setClauses = append(setClauses, fmt.Sprintf("surname = $%d, surname_key = $%d, surname_latin = $%d", argPos, argPos+1, argPos+2))
+        args = append(args, p.Surname, names.Key(p.Surname), names.LatinKeys(p.Surname))
+        argPos += 3
     }
     if p.Patronymic != nil && *p.Patronymic != currPatronymic.String {
-        setClauses = append(setClauses, fmt.Sprintf("patronymic = $%d", argPos))
-        args = append(args, *p.Patronymic)
-        argPos++
+        setClauses = append(setClauses, fmt.Sprintf("patronymic = NULLIF($%d, ''), patronymic_key = NULLIF($%d, ''), patronymic_latin = $%d", argPos, argPos+1, argPos+2))
+        args = append(args, *p.Patronymic, names.Key(*p.Patronymic), names.LatinKeys(*p.Patronymic))
+        argPos += 3
     }
-    if p.Age != 0 && int64(p.Age) != currAge.Int64 {
+    if p.Age != 0 && (!currAge.Valid || int64(p.Age) != currAge.Int64) {
         setClauses = append(setClauses, fmt.Sprintf("age = $%d", argPos))
         args = append(args, p.Age)
         argPos++
+        changed = append(changed, entities.FieldAge)
     }
     if newGenderID != currGenderID {
         setClauses = append(setClauses, fmt.Sprintf("gender_id = $%d", argPos))
         args = append(args, newGenderID)
         argPos++
+        changed = append(changed, entities.FieldGender)
     }
     if newNationalityID != currNationalityID {
         setClauses = append(setClauses, fmt.Sprintf("nationality_id = $%d", argPos))
         args = append(args, newNationalityID)
         argPos++
+        changed = append(changed, entities.FieldNationality)
+    }
+
+    // fields set explicitly are no longer waiting for enrichment
+    resolved := []string{}
+    if p.Age != 0 {
+        resolved = append(resolved, entities.FieldAge)
+    }
+    if p.Gender != "" {
+        resolved = append(resolved, entities.FieldGender)
+    }
+    if p.Nationality != "" {
+        resolved = append(resolved, entities.FieldNationality)
+    }
+    if len(p.Locked) > 0 {
+        setClauses = append(setClauses, fmt.Sprintf("locked_fields = ARRAY(SELECT DISTINCT f FROM unnest(locked_fields || $%d::text[]) f ORDER BY f)", argPos))
+        args = append(args, p.Locked)
+        argPos++
+    }
+    if len(setClauses) > 0 && len(resolved) > 0 {
+        setClauses = append(setClauses, fmt.Sprintf("pending_fields = ARRAY(SELECT f FROM unnest(pending_fields) f WHERE f <> ALL($%d))", argPos))
+        // once nothing is pending the failed attempts no longer matter
+        setClauses = append(setClauses, fmt.Sprintf("enrichment_attempts = CASE WHEN pending_fields <@ $%d::text[] THEN 0 ELSE enrichment_attempts END", argPos))
+        args = append(args, resolved)
+        argPos++
     }
 
     if len(setClauses) > 0 {
         args = append(args, id)
         query = fmt.Sprintf("UPDATE people SET %s WHERE id = $%d", strings.Join(setClauses, ", "), argPos)
-        result, err := tx.Exec(query, args...)
+        result, err := tx.ExecContext(ctx, query, args...)
         if err != nil {
             Log.Info("Failed to update people", "id", id, "error", err)
-            return fmt.Errorf("failed to update person: %w", err)
+            return nil, dbError("failed to update person", err)
         }
         rows, err := result.RowsAffected()
         if err != nil {
             Log.Info("Failed to retrieve affected rows", "id", id, "error", err)
-            return fmt.Errorf("failed to retrieve affected rows: %w", err)
+            return nil, dbError("failed to retrieve affected rows", err)
         }
         if rows == 0 {
             Log.Info("No person record updated", "id", id)
-            return sql.ErrNoRows
+            return nil, apperrors.NotFound("Person not found")
         }
     }
 
+    // provenance of a field changed without a new one no longer describes it
+    stale := []string{}
+    for _, field := range changed {
+        if _, ok := p.Enrichment[field]; !ok {
+            stale = append(stale, field)
+        }
+    }
+    if err = deleteEnrichment(ctx, tx, id, stale); err != nil {
+        return nil, err
+    }
+    if err = saveEnrichment(ctx, tx, id, p.Enrichment); err != nil {
+        return nil, err
+    }
+
     if err := tx.Commit(); err != nil {
         Log.Error("Failed to commit transaction", "error", err)
-        return fmt.Errorf("transaction commit failed: %w", err)
+        return nil, dbError("transaction commit failed", err)
     }
 
     Log.Info("Person and related data updated successfully", "id", id, "updates", p)
-    return nil
+    return skipped, nil
 }
 
-func (r *PersonRepository) CreatePerson(person entities.Person) (int, error) {
-	tx, err := r.db.Begin()
+// withoutLocked clears the locked fields of an enrichment update and returns
+// the ones that were set
+func withoutLocked(p entities.Person, locked []string) (entities.Person, []string) {
+	var skipped []string
+	enrichment := map[string]entities.Provenance{}
+	for field, provenance := range p.Enrichment {
+		if !slices.Contains(locked, field) {
+			enrichment[field] = provenance
+		}
+	}
+
+	for _, field := range locked {
+		switch {
+		case field == entities.FieldAge && p.Age != 0:
+			p.Age = 0
+		case field == entities.FieldGender && p.Gender != "":
+			p.Gender = ""
+		case field == entities.FieldNationality && p.Nationality != "":
+			p.Nationality = ""
+		default:
+			continue
+		}
+		skipped = append(skipped, field)
+	}
+	p.Enrichment = enrichment
+
+	return p, skipped
+}
+
+func (r *PersonRepository) CreatePerson(ctx context.Context, person entities.Person) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
         Log.Error("Failed to begin transaction", "error", err)
 		
-        return 0, fmt.Errorf("failed to start transaction: %w", err)
+        return 0, dbError("failed to start transaction", err)
     }
-    defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
+    defer tx.Rollback()
 
-	var genderID int
-	err = tx.QueryRow(`SELECT id FROM genders WHERE gender = $1`, person.Gender).Scan(&genderID)
-	if err != nil {
-		err = r.db.QueryRow(`INSERT INTO genders (gender) VALUES ($1) RETURNING id`, person.Gender).Scan(&genderID)
+	// fields still pending enrichment are stored as NULL, as is an empty gender
+	// or nationality rather than a lookup row for ""
+	var age, genderID, nationalityID *int
+
+	if !slices.Contains(person.Pending, entities.FieldAge) {
+		age = &person.Age
+	}
+
+	if !slices.Contains(person.Pending, entities.FieldGender) && person.Gender != "" {
+		genderID = new(int)
+		err = tx.QueryRowContext(ctx, `SELECT id FROM genders WHERE gender = $1`, person.Gender).Scan(genderID)
 		if err != nil {
-			Log.Info("Failed to insert gender", "gender", person.Gender, "error", err)
-			return 0, fmt.Errorf("failed to insert gender: %w", err)
+			err = tx.QueryRowContext(ctx, `INSERT INTO genders (gender) VALUES ($1) RETURNING id`, person.Gender).Scan(genderID)
+			if err != nil {
+				Log.Info("Failed to insert gender", "gender", person.Gender, "error", err)
+				return 0, dbError("failed to insert gender", err)
+			}
 		}
 	}
 
-	var nationalityID int
-	err = tx.QueryRow(`SELECT id FROM nationalities WHERE nationality = $1`, person.Nationality).Scan(&nationalityID)
-	if err != nil {
-		err = r.db.QueryRow(`INSERT INTO nationalities (nationality) VALUES ($1) RETURNING id`, person.Nationality).Scan(&nationalityID)
+	if !slices.Contains(person.Pending, entities.FieldNationality) && person.Nationality != "" {
+		nationalityID = new(int)
+		err = tx.QueryRowContext(ctx, `SELECT id FROM nationalities WHERE nationality = $1`, person.Nationality).Scan(nationalityID)
 		if err != nil {
-			Log.Info("Failed to insert nationality", "nationality", person.Nationality, "error", err)
-			return 0, fmt.Errorf("failed to insert nationality: %w", err)
+			err = tx.QueryRowContext(ctx, `INSERT INTO nationalities (nationality) VALUES ($1) RETURNING id`, person.Nationality).Scan(nationalityID)
+			if err != nil {
+				Log.Info("Failed to insert nationality", "nationality", person.Nationality, "error", err)
+				return 0, dbError("failed to insert nationality", err)
+			}
 		}
 	}
 
+	pending := person.Pending
+	if pending == nil {
+		pending = []string{}
+	}
+
 	query := `
-		INSERT INTO people (name, surname, patronymic, age, gender_id, nationality_id)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO people (name, surname, patronymic, age, gender_id, nationality_id, pending_fields, name_key, surname_key, patronymic_key, name_latin, surname_latin, patronymic_latin)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, $12, $13)
 		RETURNING id
 	`
 
 	var id int
-	err = tx.QueryRow(query,
+	err = tx.QueryRowContext(ctx, query,
 		person.Name,
 		person.Surname,
 		person.Patronymic,
-		person.Age,
+		age,
 		genderID,
 		nationalityID,
+		pending,
+		names.Key(person.Name),
+		names.Key(person.Surname),
+		names.Key(optional(person.Patronymic)),
+		names.LatinKeys(person.Name),
+		names.LatinKeys(person.Surname),
+		names.LatinKeys(optional(person.Patronymic)),
 	).Scan(&id)
 
 	if err != nil {
 		Log.Info("Failed to insert person", "person", person, "error", err)
-		return 0, fmt.Errorf("failed to insert person: %w", err)
+		return 0, dbError("failed to insert person", err)
+	}
+
+	if err = saveEnrichment(ctx, tx, id, person.Enrichment); err != nil {
+		return 0, err
 	}
 
 	if err := tx.Commit(); err != nil {
         Log.Error("Failed to commit transaction", "error", err)
 
-        return 0, fmt.Errorf("transaction commit failed: %w", err)
+        return 0, dbError("transaction commit failed", err)
     }
 
 	Log.Info("Person created successfully", "person", person, "id", id)
 	return id, nil
 }
 
-func (r *PersonRepository) GetPersonByID(id int) (entities.Person, error) {
+func (r *PersonRepository) GetPersonByID(ctx context.Context, id int) (entities.Person, error) {
 	query := `
-		SELECT p.name, p.surname, p.patronymic, p.age, g.gender AS gender, n.nationality AS nationality
+		SELECT ` + personColumns + `
 		FROM people p
-		JOIN genders g ON p.gender_id = g.id
-		JOIN nationalities n ON p.nationality_id = n.id
+		LEFT JOIN genders g ON p.gender_id = g.id
+		LEFT JOIN nationalities n ON p.nationality_id = n.id
 		WHERE p.id = $1
 	`
 
-	row := r.db.QueryRow(query, id)
+	row := r.db.QueryRowContext(ctx, query, id)
 
-	var p entities.Person
-	err := row.Scan(&p.Name, &p.Surname, &p.Patronymic, &p.Age, &p.Gender, &p.Nationality)
+	p, err := scanPerson(row)
 
 	if err != nil {
 		Log.Info("Failed to get person by ID", "id", id, "error", err)
 
+		return entities.Person{}, dbError("failed to get person", err)
+	}
+
+	p.Enrichment, err = r.getEnrichment(ctx, id)
+	if err != nil {
 		return entities.Person{}, err
 	}
 
@@ -239,122 +367,310 @@ func (r *PersonRepository) GetPersonByID(id int) (entities.Person, error) {
 	return p, nil
 }
 
-func (r *PersonRepository) GetPeopleByFilter(filter entities.Person, page, limit string) ([]entities.Person, error) {
-	query := `
-		SELECT p.name, p.surname, p.patronymic, p.age, g.gender, n.nationality
-		FROM people p
-		JOIN genders g ON p.gender_id = g.id
-		JOIN nationalities n ON p.nationality_id = n.id
-		WHERE 1=1
-	`
-
-	args := []interface{}{}
-	argIdx := 1
-
-	if filter.Name != "" {
-		query += fmt.Sprintf(" AND p.name = $%d", argIdx)
-		args = append(args, filter.Name)
-		argIdx++
-	}
-	if filter.Surname != "" {
-		query += fmt.Sprintf(" AND p.surname = $%d", argIdx)
-		args = append(args, filter.Surname)
-		argIdx++
-	}
-	if filter.Patronymic != nil && *filter.Patronymic != "" {
-		query += fmt.Sprintf(" AND p.patronymic = $%d", argIdx)
-		args = append(args, *filter.Patronymic)
-		argIdx++
-	}
-	if filter.Age != 0 {
-		query += fmt.Sprintf(" AND p.age = $%d", argIdx)
-		args = append(args, filter.Age)
-		argIdx++
-	}
-	if filter.Gender != "" {
-		query += fmt.Sprintf(" AND g.gender = $%d", argIdx)
-		args = append(args, filter.Gender)
-		argIdx++
-	}
-	if filter.Nationality != "" {
-		query += fmt.Sprintf(" AND n.nationality = $%d", argIdx)
-		args = append(args, filter.Nationality)
-		argIdx++
+// GetPeopleByFilter matches names by their normalized keys, or by their
+// Latin spellings in any script when filter.Translit is set. It returns up to
+// limit people ordered by sort, starting after the cursor when it is set,
+// and the cursor of the next page when there is one.
+func (r *PersonRepository) GetPeopleByFilter(ctx context.Context, filter entities.PersonFilter, sort []entities.SortKey, after *entities.Cursor, limit int) ([]entities.Person, *entities.Cursor, error) {
+	c := personConditions(filter)
+	order := newListOrder(sort)
+
+	if after != nil {
+		if err := order.after(c, after); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
-	args = append(args, limit, page)
+	query := `
+		SELECT ` + personColumns + order.columns() + `
+		FROM people p
+		LEFT JOIN genders g ON p.gender_id = g.id
+		LEFT JOIN nationalities n ON p.nationality_id = n.id
+	` + c.where() + order.orderBy() + " LIMIT " + c.arg(limit+1)
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, c.args...)
 	if err != nil {
 		Log.Info("Failed to query people with filters", "filter", filter, "error", err)
-		return nil, err
+		return nil, nil, dbError("failed to query people", err)
 	}
 	defer rows.Close()
 
 	var people []entities.Person
+	var next *entities.Cursor
+	var lastValues []*string
 	for rows.Next() {
-		var p entities.Person
-		err := rows.Scan(&p.Name, &p.Surname, &p.Patronymic, &p.Age, &p.Gender, &p.Nationality)
+		values := make([]*string, len(order.terms))
+		extra := make([]any, len(values))
+		for i := range values {
+			extra[i] = &values[i]
+		}
+
+		p, err := scanPerson(rows, extra...)
 		if err != nil {
 			Log.Info("Failed to scan row", "error", err)
-			return nil, err
+			return nil, nil, dbError("failed to scan person", err)
+		}
+
+		if len(people) == limit {
+			next = &entities.Cursor{Values: lastValues, ID: people[limit-1].ID}
+			break
 		}
 		people = append(people, p)
+		lastValues = values
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, dbError("failed to read people", err)
 	}
 
 	Log.Info("People retrieved with filters", "filter", filter, "count", len(people))
-	return people, nil
+	return people, next, nil
 }
 
-func (r *PersonRepository) DeletePersonByID(id int) error {
+func (r *PersonRepository) DeletePersonByID(ctx context.Context, id int) error {
 	Log.Debug("Deleting person by ID", "ID", id)
 
-	tx, err := r.db.Begin()
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		Log.Error("Failed to begin transaction", "error", err)
-        return fmt.Errorf("failed to start transaction: %w", err)
+        return dbError("failed to start transaction", err)
 	}
 
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
+	defer tx.Rollback()
 
-	var genderID, nationalityID int
+	var genderID, nationalityID sql.NullInt64
 
-	err = tx.QueryRow(`SELECT gender_id, nationality_id FROM people WHERE id = $1`, id).Scan(&genderID, &nationalityID)
+	err = tx.QueryRowContext(ctx, `SELECT gender_id, nationality_id FROM people WHERE id = $1`, id).Scan(&genderID, &nationalityID)
 	if err != nil {
 		Log.Info("Failed to get gender/nationality ID for person", "ID", id, "error", err)
 
-		return err
+		return dbError("failed to get person", err)
 	}
 
-	_, err = tx.Exec(`DELETE FROM people WHERE id = $1`, id)
+	_, err = tx.ExecContext(ctx, `DELETE FROM people WHERE id = $1`, id)
 	if err != nil {
 		Log.Info("Failed to delete person", "ID", id, "error", err)
 
-		return err
+		return dbError("failed to delete person", err)
 	}
 
-	_, _ = tx.Exec(`
-		DELETE FROM genders 
-		WHERE id = $1
-		`, genderID)
+	if genderID.Valid {
+		_, _ = tx.ExecContext(ctx, `
+			DELETE FROM genders
+			WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM people WHERE gender_id = $1)
+			`, genderID)
+	}
 
-	_, _ = tx.Exec(`
-		DELETE FROM nationalities 
-		WHERE id = $1
-		`, nationalityID)
+	if nationalityID.Valid {
+		_, _ = tx.ExecContext(ctx, `
+			DELETE FROM nationalities
+			WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM people WHERE nationality_id = $1)
+			`, nationalityID)
+	}
 
 	if err := tx.Commit(); err != nil {
         Log.Error("Failed to commit transaction", "error", err)
 
-        return fmt.Errorf("transaction commit failed: %w", err)
+        return dbError("transaction commit failed", err)
     }
 
 	Log.Debug("Successfully deleted person and checked for unused gender/nationality", "ID", id)
 
 	return nil
-}
\ No newline at end of file
+}
+
+func (r *PersonRepository) GetPendingEnrichment(ctx context.Context, limit, maxAttempts int) ([]entities.Person, error) {
+	query := `
+		SELECT ` + personColumns + `
+		FROM people p
+		LEFT JOIN genders g ON p.gender_id = g.id
+		LEFT JOIN nationalities n ON p.nationality_id = n.id
+		WHERE cardinality(p.pending_fields) > 0 AND p.enrichment_attempts < $1
+			AND EXISTS (SELECT 1 FROM unnest(p.pending_fields) f WHERE f <> ALL(p.locked_fields))
+		ORDER BY p.id
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, maxAttempts, limit)
+	if err != nil {
+		Log.Info("Failed to query people pending enrichment", "error", err)
+		return nil, dbError("failed to query people pending enrichment", err)
+	}
+	defer rows.Close()
+
+	var people []entities.Person
+	for rows.Next() {
+		p, err := scanPerson(rows)
+		if err != nil {
+			Log.Info("Failed to scan row", "error", err)
+			return nil, dbError("failed to scan person", err)
+		}
+		people = append(people, p)
+	}
+
+	return people, rows.Err()
+}
+
+func (r *PersonRepository) RecordEnrichmentAttempt(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE people SET enrichment_attempts = enrichment_attempts + 1 WHERE id = $1`, id)
+	if err != nil {
+		Log.Info("Failed to record enrichment attempt", "id", id, "error", err)
+		return dbError("failed to record enrichment attempt", err)
+	}
+
+	return nil
+}
+
+// GetStaleEnrichment returns people with an unlocked field whose provenance
+// is older than enrichedBefore, less confident than minConfidence or missing,
+// skipping those checked since checkedBefore. Results are ordered by id and
+// start after afterID.
+func (r *PersonRepository) GetStaleEnrichment(ctx context.Context, enrichedBefore time.Time, minConfidence float64, checkedBefore time.Time, afterID, limit int) ([]entities.Person, error) {
+	query := `
+		SELECT ` + personColumns + `
+		FROM people p
+		LEFT JOIN genders g ON p.gender_id = g.id
+		LEFT JOIN nationalities n ON p.nationality_id = n.id
+		WHERE p.id > $1
+			AND (p.enrichment_checked_at IS NULL OR p.enrichment_checked_at < $2)
+			AND EXISTS (
+				SELECT 1 FROM unnest($5::text[]) f
+				LEFT JOIN person_enrichment e ON e.person_id = p.id AND e.field = f
+				WHERE f <> ALL(p.locked_fields)
+					AND (e.person_id IS NULL OR e.enriched_at < $3 OR e.probability < $4)
+			)
+		ORDER BY p.id
+		LIMIT $6
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, afterID, checkedBefore, enrichedBefore, minConfidence, entities.EnrichedFields, limit)
+	if err != nil {
+		Log.Info("Failed to query people with stale enrichment", "error", err)
+		return nil, dbError("failed to query people with stale enrichment", err)
+	}
+	defer rows.Close()
+
+	var people []entities.Person
+	for rows.Next() {
+		p, err := scanPerson(rows)
+		if err != nil {
+			Log.Info("Failed to scan row", "error", err)
+			return nil, dbError("failed to scan person", err)
+		}
+		people = append(people, p)
+	}
+
+	return people, rows.Err()
+}
+
+func (r *PersonRepository) MarkEnrichmentChecked(ctx context.Context, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := r.db.ExecContext(ctx, `UPDATE people SET enrichment_checked_at = now() WHERE id = ANY($1)`, ids)
+	if err != nil {
+		Log.Info("Failed to mark enrichment checked", "ids", ids, "error", err)
+		return dbError("failed to mark enrichment checked", err)
+	}
+
+	return nil
+}
+
+func (r *PersonRepository) RecordEnrichmentAudit(ctx context.Context, audits []entities.EnrichmentAudit) error {
+	for _, audit := range audits {
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO enrichment_audit (person_id, field, old_value, new_value, provider, job, changed_at)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
+		`, audit.PersonID, audit.Field, audit.OldValue, audit.NewValue, audit.Provider, audit.Job, audit.ChangedAt)
+
+		if err != nil {
+			Log.Info("Failed to record enrichment audit", "id", audit.PersonID, "field", audit.Field, "error", err)
+			return dbError("failed to record enrichment audit", err)
+		}
+	}
+
+	return nil
+}
+
+// SetFieldLock locks or unlocks field and returns the locked fields of the
+// person. A locked field stops waiting for enrichment.
+func (r *PersonRepository) SetFieldLock(ctx context.Context, id int, field string, locked bool) ([]string, error) {
+	query := `
+		UPDATE people
+		SET locked_fields = array_remove(locked_fields, $2)
+		WHERE id = $1
+		RETURNING to_json(locked_fields)
+	`
+	if locked {
+		query = `
+			UPDATE people
+			SET locked_fields = array_append(array_remove(locked_fields, $2), $2),
+				pending_fields = array_remove(pending_fields, $2)
+			WHERE id = $1
+			RETURNING to_json(locked_fields)
+		`
+	}
+
+	var fields textArray
+	if err := r.db.QueryRowContext(ctx, query, id, field).Scan(&fields); err != nil {
+		Log.Info("Failed to set field lock", "id", id, "field", field, "locked", locked, "error", err)
+		return nil, dbError("failed to set field lock", err)
+	}
+
+	return fields, nil
+}
+
+// BackfillLatinKeys transliterates the names of people stored before Latin
+// keys existed, batchSize rows at a time, and returns how many were updated
+func (r *PersonRepository) BackfillLatinKeys(ctx context.Context, batchSize int) (int, error) {
+	total := 0
+	for {
+		rows, err := r.db.QueryContext(ctx, `
+			SELECT id, name, surname, COALESCE(patronymic, '')
+			FROM people
+			WHERE latin_keys_pending
+			ORDER BY id
+			LIMIT $1
+		`, batchSize)
+		if err != nil {
+			return total, dbError("failed to query people without latin keys", err)
+		}
+
+		type person struct {
+			id                        int
+			name, surname, patronymic string
+		}
+		var people []person
+		for rows.Next() {
+			var p person
+			if err := rows.Scan(&p.id, &p.name, &p.surname, &p.patronymic); err != nil {
+				rows.Close()
+				return total, dbError("failed to scan person", err)
+			}
+			people = append(people, p)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return total, dbError("failed to read people without latin keys", err)
+		}
+
+		if len(people) == 0 {
+			return total, nil
+		}
+
+		for _, p := range people {
+			_, err := r.db.ExecContext(ctx, `
+				UPDATE people
+				SET name_latin = $2, surname_latin = $3, patronymic_latin = $4, latin_keys_pending = false
+				WHERE id = $1
+			`, p.id, names.LatinKeys(p.name), names.LatinKeys(p.surname), names.LatinKeys(p.patronymic))
+			if err != nil {
+				return total, dbError("failed to save latin keys", err)
+			}
+		}
+
+		total += len(people)
+		Log.Debug("Latin keys backfilled", "count", total)
+	}
+}
@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/names"
+	. "github.com/agl/fio/pkg/logger"
+)
+
+// SearchPeople finds people whose name, surname or patronymic is similar to
+// query by trigrams, best matches first. Only matches with a similarity of
+// at least threshold are returned.
+func (r *PersonRepository) SearchPeople(ctx context.Context, query string, threshold float64, limit int) ([]entities.SearchHit, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, dbError("failed to start transaction", err)
+	}
+	defer tx.Rollback()
+
+	// the % operator uses the session threshold, set it for this transaction only
+	_, err = tx.ExecContext(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, strconv.FormatFloat(threshold, 'f', -1, 64))
+	if err != nil {
+		return nil, dbError("failed to set similarity threshold", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+personColumns+`,
+			GREATEST(
+				similarity(p.name_key, $1),
+				similarity(p.surname_key, $1),
+				COALESCE(similarity(p.patronymic_key, $1), 0)
+			) AS score
+		FROM people p
+		LEFT JOIN genders g ON p.gender_id = g.id
+		LEFT JOIN nationalities n ON p.nationality_id = n.id
+		WHERE p.name_key % $1 OR p.surname_key % $1 OR p.patronymic_key % $1
+		ORDER BY score DESC, p.id
+		LIMIT $2
+	`, names.Key(query), limit)
+	if err != nil {
+		Log.Info("Failed to search people", "query", query, "error", err)
+		return nil, dbError("failed to search people", err)
+	}
+	defer rows.Close()
+
+	var hits []entities.SearchHit
+	for rows.Next() {
+		var hit entities.SearchHit
+		hit.Person, err = scanPerson(rows, &hit.Score)
+		if err != nil {
+			Log.Info("Failed to scan row", "error", err)
+			return nil, dbError("failed to scan person", err)
+		}
+		hits = append(hits, hit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, dbError("failed to read search results", err)
+	}
+
+	Log.Info("People searched", "query", query, "count", len(hits))
+	return hits, nil
+}
+
+// FullTextSearchPeople finds people whose names contain every word as a
+// prefix, stemmed by the russian configuration or as written, ranked by
+// ts_rank. The matched words are highlighted in the full name.
+func (r *PersonRepository) FullTextSearchPeople(ctx context.Context, words []string, limit int) ([]entities.SearchHit, error) {
+	terms := make([]string, len(words))
+	args := make([]any, 0, len(words)+1)
+	for i, word := range words {
+		terms[i] = fmt.Sprintf("(to_tsquery('russian', $%[1]d) || to_tsquery('simple', $%[1]d))", i+1)
+		args = append(args, word+":*")
+	}
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, `
+		WITH q AS (SELECT `+strings.Join(terms, " && ")+` AS query)
+		SELECT `+personColumns+`,
+			ts_rank(p.search_vector, q.query) AS score,
+			ts_headline('russian', concat_ws(' ', p.surname, p.name, p.patronymic), q.query,
+				'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS highlight
+		FROM people p
+		CROSS JOIN q
+		LEFT JOIN genders g ON p.gender_id = g.id
+		LEFT JOIN nationalities n ON p.nationality_id = n.id
+		WHERE p.search_vector @@ q.query
+		ORDER BY score DESC, p.id
+		LIMIT `+fmt.Sprintf("$%d", len(args)), args...)
+	if err != nil {
+		Log.Info("Failed to search people by full text", "words", words, "error", err)
+		return nil, dbError("failed to search people", err)
+	}
+	defer rows.Close()
+
+	var hits []entities.SearchHit
+	for rows.Next() {
+		var hit entities.SearchHit
+		hit.Person, err = scanPerson(rows, &hit.Score, &hit.Highlight)
+		if err != nil {
+			Log.Info("Failed to scan row", "error", err)
+			return nil, dbError("failed to scan person", err)
+		}
+		hits = append(hits, hit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, dbError("failed to read search results", err)
+	}
+
+	Log.Info("People searched by full text", "words", words, "count", len(hits))
+	return hits, nil
+}
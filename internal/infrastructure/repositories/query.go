@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/agl/fio/internal/domain/names"
+	"github.com/agl/fio/internal/domain/query"
+)
+
+// queryColumn is the SQL side of a query field: expr is compared with the
+// values mapped by key, column is NULL when the value is missing
+type queryColumn struct {
+	expr   string
+	column string
+	key    func(string) string
+}
+
+var queryColumns = map[string]queryColumn{
+	"name":        {"p.name_key", "p.name", names.Key},
+	"surname":     {"p.surname_key", "p.surname", names.Key},
+	"patronymic":  {"p.patronymic_key", "p.patronymic", names.Key},
+	"age":         {"p.age", "p.age", asIs},
+	"gender":      {"g.gender", "p.gender_id", asIs},
+	"nationality": {"n.nationality", "p.nationality_id", asIs},
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// compile translates a parsed query into a parameterized clause. NOT treats
+// an unknown comparison as false, so NOT age>30 keeps people without an age.
+func (c *conditions) compile(node query.Node) string {
+	switch n := node.(type) {
+	case *query.And:
+		return "(" + c.compile(n.Left) + " AND " + c.compile(n.Right) + ")"
+	case *query.Or:
+		return "(" + c.compile(n.Left) + " OR " + c.compile(n.Right) + ")"
+	case *query.Not:
+		return "NOT COALESCE(" + c.compile(n.Expr) + ", false)"
+	case *query.Comparison:
+		return c.compileComparison(n)
+	default:
+		panic(fmt.Sprintf("unexpected query node %T", node))
+	}
+}
+
+func (c *conditions) compileComparison(cmp *query.Comparison) string {
+	col := queryColumns[cmp.Field]
+
+	value := cmp.Value
+	if s, ok := value.(string); ok {
+		value = col.key(s)
+	}
+
+	switch {
+	case value == nil && cmp.Op == query.Eq:
+		return col.column + " IS NULL"
+	case value == nil:
+		return col.column + " IS NOT NULL"
+	case cmp.Op == query.Eq:
+		return col.expr + " = " + c.arg(value)
+	case cmp.Op == query.Ne:
+		return col.expr + " IS DISTINCT FROM " + c.arg(value)
+	case cmp.Op == query.Contains:
+		return col.expr + ` LIKE '%' || ` + c.arg(likeEscaper.Replace(value.(string))) + ` || '%'`
+	default:
+		return col.expr + " " + string(cmp.Op) + " " + c.arg(value)
+	}
+}
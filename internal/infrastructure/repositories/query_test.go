@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/agl/fio/internal/domain/query"
+)
+
+func TestCompileQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		sql   string
+		args  string
+	}{
+		{
+			`age>=30 AND (nationality:RU OR nationality:KZ) AND surname~"ov"`,
+			`((p.age >= $1 AND (n.nationality = $2 OR n.nationality = $3)) AND p.surname_key LIKE '%' || $4 || '%')`,
+			`[30 RU KZ ov]`,
+		},
+		{
+			`age:1 OR age:2 AND age:3`,
+			`(p.age = $1 OR (p.age = $2 AND p.age = $3))`,
+			`[1 2 3]`,
+		},
+		{
+			`NOT age>30`,
+			`NOT COALESCE(p.age > $1, false)`,
+			`[30]`,
+		},
+		{
+			`gender!=male`,
+			`g.gender IS DISTINCT FROM $1`,
+			`[male]`,
+		},
+		{
+			`patronymic:null OR nationality!=null`,
+			`(p.patronymic IS NULL OR p.nationality_id IS NOT NULL)`,
+			`[]`,
+		},
+		{
+			`name:"Ёлкин"`,
+			`p.name_key = $1`,
+			`[елкин]`,
+		},
+		{
+			`surname~"50%_off\\"`,
+			`p.surname_key LIKE '%' || $1 || '%'`,
+			`[50\%\_off\\]`,
+		},
+		{
+			`surname~"'; DROP TABLE people; --"`,
+			`p.surname_key LIKE '%' || $1 || '%'`,
+			`['; drop table people; --]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			node, err := query.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.input, err)
+			}
+
+			c := &conditions{}
+			sql := c.compile(node)
+
+			if sql != tt.sql {
+				t.Errorf("compile(%q) =\n%s\nwant\n%s", tt.input, sql, tt.sql)
+			}
+			if args := fmt.Sprint(c.args); args != tt.args {
+				t.Errorf("compile(%q) args = %s, want %s", tt.input, args, tt.args)
+			}
+		})
+	}
+}
+
+func TestCompileQueryAfterFilter(t *testing.T) {
+	node, err := query.Parse(`age>18`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &conditions{}
+	c.add("g.gender = %s", "male")
+	c.clauses = append(c.clauses, c.compile(node))
+
+	want := ` WHERE g.gender = $1 AND p.age > $2`
+	if got := c.where(); got != want {
+		t.Errorf("where() = %q, want %q", got, want)
+	}
+}
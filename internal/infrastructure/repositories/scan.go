@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/agl/fio/internal/domain/entities"
+)
+
+// personColumns selects a person joined with genders (g) and nationalities (n).
+// Fields pending enrichment are NULL and come back as zero values.
+const personColumns = `
+	p.id, p.name, p.surname, p.patronymic,
+	COALESCE(p.age, 0), COALESCE(g.gender, ''), COALESCE(n.nationality, ''),
+	to_json(p.pending_fields), to_json(p.locked_fields)`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanPerson scans personColumns followed by the extra columns of a query
+func scanPerson(row scanner, extra ...any) (entities.Person, error) {
+	var p entities.Person
+	var pending, locked textArray
+
+	dest := []any{&p.ID, &p.Name, &p.Surname, &p.Patronymic, &p.Age, &p.Gender, &p.Nationality, &pending, &locked}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return entities.Person{}, err
+	}
+
+	if len(pending) > 0 {
+		p.Pending = pending
+	}
+	if len(locked) > 0 {
+		p.Locked = locked
+	}
+
+	return p, nil
+}
+
+// textArray scans a TEXT[] column selected through to_json()
+type textArray []string
+
+func (a *textArray) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, (*[]string)(a))
+	case string:
+		return json.Unmarshal([]byte(v), (*[]string)(a))
+	default:
+		return fmt.Errorf("cannot scan %T into text array", src)
+	}
+}
+
+func optional(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/agl/fio/internal/domain/apperrors"
+	"github.com/agl/fio/internal/domain/entities"
+)
+
+type sortColumn struct {
+	expr     string
+	nullable bool
+	numeric  bool
+}
+
+// sortColumns are the expressions a list is ordered by. Names sort by their
+// keys, so neither case nor ё and е change the order.
+var sortColumns = map[string]sortColumn{
+	"name":                    {"p.name_key", false, false},
+	"surname":                 {"p.surname_key", false, false},
+	"patronymic":              {"p.patronymic_key", true, false},
+	entities.FieldAge:         {"p.age", true, true},
+	entities.FieldGender:      {"g.gender", true, false},
+	entities.FieldNationality: {"n.nationality", true, false},
+}
+
+type sortTerm struct {
+	sortColumn
+	desc bool
+}
+
+// listOrder is the order of a list: the sort keys and then the id, so equal
+// rows keep their order between pages. The id follows the direction of the
+// last key, which lets an index on (column, id) serve a single key in either
+// direction. Missing values come last in ascending order and first in
+// descending, as in Postgres.
+type listOrder struct {
+	terms  []sortTerm
+	idDesc bool
+}
+
+func newListOrder(sort []entities.SortKey) listOrder {
+	var o listOrder
+	for _, key := range sort {
+		o.idDesc = key.Desc
+		if key.Field == "id" {
+			break
+		}
+
+		o.terms = append(o.terms, sortTerm{sortColumns[key.Field], key.Desc})
+	}
+
+	return o
+}
+
+func (o listOrder) orderBy() string {
+	var terms []string
+	for _, t := range o.terms {
+		terms = append(terms, t.expr+direction(t.desc))
+	}
+
+	return " ORDER BY " + strings.Join(append(terms, "p.id"+direction(o.idDesc)), ", ")
+}
+
+// columns selects the sort values of a row as text for its cursor
+func (o listOrder) columns() string {
+	var columns string
+	for _, t := range o.terms {
+		columns += ", " + t.expr + "::text"
+	}
+
+	return columns
+}
+
+// after adds the condition selecting the rows that follow the cursor. When
+// every column is NOT NULL and sorted the same way a row comparison lets the
+// index seek straight to the cursor; otherwise the condition is spelled out
+// key by key.
+func (o listOrder) after(c *conditions, cursor *entities.Cursor) error {
+	if len(cursor.Values) != len(o.terms) {
+		return apperrors.InvalidField("after", "is not a valid cursor")
+	}
+
+	values := make([]any, len(o.terms))
+	rowComparison := true
+	for i, t := range o.terms {
+		v := cursor.Values[i]
+		if v != nil && t.numeric {
+			n, err := strconv.Atoi(*v)
+			if err != nil {
+				return apperrors.InvalidField("after", "is not a valid cursor")
+			}
+			values[i] = n
+		} else if v != nil {
+			values[i] = *v
+		}
+
+		rowComparison = rowComparison && !t.nullable && t.desc == o.idDesc
+	}
+
+	if rowComparison {
+		exprs := []string{}
+		placeholders := []string{}
+		for i, t := range o.terms {
+			exprs = append(exprs, t.expr)
+			placeholders = append(placeholders, c.arg(values[i]))
+		}
+		exprs = append(exprs, "p.id")
+		placeholders = append(placeholders, c.arg(cursor.ID))
+
+		c.clauses = append(c.clauses, "("+strings.Join(exprs, ", ")+") "+greater(o.idDesc)+" ("+strings.Join(placeholders, ", ")+")")
+
+		return nil
+	}
+
+	var equal, alternatives []string
+	for i, t := range o.terms {
+		placeholder := ""
+		if values[i] != nil {
+			placeholder = c.arg(values[i])
+		}
+
+		if follows := t.follows(placeholder); follows != "" {
+			alternatives = append(alternatives, "("+strings.Join(append(equal, follows), " AND ")+")")
+		}
+
+		if placeholder == "" {
+			equal = append(equal, t.expr+" IS NULL")
+		} else {
+			equal = append(equal, t.expr+" = "+placeholder)
+		}
+	}
+	follows := "p.id " + greater(o.idDesc) + " " + c.arg(cursor.ID)
+	alternatives = append(alternatives, "("+strings.Join(append(equal, follows), " AND ")+")")
+
+	c.clauses = append(c.clauses, "("+strings.Join(alternatives, " OR ")+")")
+
+	return nil
+}
+
+// follows is the condition of a value coming after the placeholder, which is
+// empty for a missing value. It is empty when nothing can follow.
+func (t sortTerm) follows(placeholder string) string {
+	switch {
+	case placeholder == "" && t.desc:
+		return t.expr + " IS NOT NULL"
+	case placeholder == "":
+		return ""
+	case t.desc:
+		return t.expr + " < " + placeholder
+	case t.nullable:
+		return "(" + t.expr + " > " + placeholder + " OR " + t.expr + " IS NULL)"
+	default:
+		return t.expr + " > " + placeholder
+	}
+}
+
+func direction(desc bool) string {
+	if desc {
+		return " DESC"
+	}
+
+	return ""
+}
+
+func greater(desc bool) string {
+	if desc {
+		return "<"
+	}
+
+	return ">"
+}
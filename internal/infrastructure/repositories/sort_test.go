@@ -0,0 +1,382 @@
+package repositories
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/agl/fio/internal/domain/entities"
+)
+
+// row holds the sort values of a person by expression, nil when missing
+type row map[string]any
+
+var sortRows = []row{
+	{"p.id": 1, "p.name_key": "ivan", "p.surname_key": "petrov", "p.patronymic_key": "ivanovich", "p.age": 30, "g.gender": "male", "n.nationality": "RU"},
+	{"p.id": 2, "p.name_key": "anna", "p.surname_key": "petrov", "p.patronymic_key": nil, "p.age": nil, "g.gender": "female", "n.nationality": nil},
+	{"p.id": 3, "p.name_key": "ivan", "p.surname_key": "abaev", "p.patronymic_key": "petrovich", "p.age": 0, "g.gender": nil, "n.nationality": "KZ"},
+	{"p.id": 4, "p.name_key": "oleg", "p.surname_key": "sidorov", "p.patronymic_key": nil, "p.age": 30, "g.gender": "male", "n.nationality": "RU"},
+	{"p.id": 5, "p.name_key": "anna", "p.surname_key": "petrov", "p.patronymic_key": "ivanovna", "p.age": nil, "g.gender": nil, "n.nationality": nil},
+	{"p.id": 6, "p.name_key": "boris", "p.surname_key": "abaev", "p.patronymic_key": "ivanovich", "p.age": 45, "g.gender": "male", "n.nationality": "KZ"},
+	{"p.id": 7, "p.name_key": "anna", "p.surname_key": "zotova", "p.patronymic_key": nil, "p.age": 7, "g.gender": "female", "n.nationality": "RU"},
+}
+
+func parseSortKeys(s string) []entities.SortKey {
+	var keys []entities.SortKey
+	for _, item := range strings.Split(s, ",") {
+		keys = append(keys, entities.SortKey{Field: strings.TrimPrefix(item, "-"), Desc: strings.HasPrefix(item, "-")})
+	}
+
+	return keys
+}
+
+func TestOrderBy(t *testing.T) {
+	tests := []struct {
+		sort string
+		want string
+	}{
+		{"id", " ORDER BY p.id"},
+		{"-id", " ORDER BY p.id DESC"},
+		{"surname", " ORDER BY p.surname_key, p.id"},
+		{"-age", " ORDER BY p.age DESC, p.id DESC"},
+		{"surname,-age", " ORDER BY p.surname_key, p.age DESC, p.id DESC"},
+		{"-nationality,name", " ORDER BY n.nationality DESC, p.name_key, p.id"},
+		{"age,-id", " ORDER BY p.age, p.id DESC"},
+		{"age,id,name", " ORDER BY p.age, p.id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			if got := newListOrder(parseSortKeys(tt.sort)).orderBy(); got != tt.want {
+				t.Errorf("orderBy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAfter(t *testing.T) {
+	tests := []struct {
+		sort          string
+		rowComparison bool
+	}{
+		{"id", true},
+		{"-id", true},
+		{"surname", true},
+		{"-surname,-name", true},
+		{"surname,-name", false},
+		{"age", false},
+		{"-age", false},
+		{"patronymic,-age", false},
+		{"-patronymic,age", false},
+		{"-nationality,name", false},
+		{"gender,age", false},
+		{"-gender,-nationality", false},
+		{"age,-id", false},
+		{"-age,id", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			order := newListOrder(parseSortKeys(tt.sort))
+			sorted := sortedRows(order)
+
+			for i, last := range sorted {
+				c := &conditions{}
+				if err := order.after(c, cursorOf(order, last)); err != nil {
+					t.Fatalf("after(%v) error: %v", last["p.id"], err)
+				}
+
+				clause := c.clauses[0]
+				if isRow := !strings.Contains(clause, " OR ") && !strings.Contains(clause, " AND "); isRow != tt.rowComparison {
+					t.Errorf("after(%v) = %s, row comparison %v, want %v", last["p.id"], clause, isRow, tt.rowComparison)
+				}
+
+				var got, want []any
+				for _, r := range sorted {
+					if evalCondition(clause, c.args, r) {
+						got = append(got, r["p.id"])
+					}
+				}
+				for _, r := range sorted[i+1:] {
+					want = append(want, r["p.id"])
+				}
+
+				if fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("after(%v) with %s %v selects %v, want %v", last["p.id"], clause, c.args, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAfterRejectsCursors(t *testing.T) {
+	order := newListOrder(parseSortKeys("surname,age"))
+	text := func(s string) *string { return &s }
+
+	for _, cursor := range []*entities.Cursor{
+		{Values: []*string{text("petrov")}, ID: 1},
+		{Values: []*string{text("petrov"), text("30"), nil}, ID: 1},
+		{Values: []*string{text("petrov"), text("thirty")}, ID: 1},
+	} {
+		if err := order.after(&conditions{}, cursor); err == nil {
+			t.Errorf("after(%v) accepted an invalid cursor", cursor.Values)
+		}
+	}
+}
+
+// cursorOf makes the cursor of a row the way the list query does, from the
+// sort values as text
+func cursorOf(order listOrder, r row) *entities.Cursor {
+	cursor := &entities.Cursor{ID: r["p.id"].(int)}
+	for _, t := range order.terms {
+		var text *string
+		if v := r[t.expr]; v != nil {
+			s := fmt.Sprint(v)
+			text = &s
+		}
+		cursor.Values = append(cursor.Values, text)
+	}
+
+	return cursor
+}
+
+// sortedRows orders the rows as Postgres does: missing values come last in
+// ascending order and first in descending
+func sortedRows(order listOrder) []row {
+	terms := append(order.terms, sortTerm{sortColumn{expr: "p.id"}, order.idDesc})
+
+	sorted := slices.Clone(sortRows)
+	slices.SortFunc(sorted, func(a, b row) int {
+		for _, t := range terms {
+			av, bv := a[t.expr], b[t.expr]
+
+			var cmp int
+			switch {
+			case av == nil && bv == nil:
+			case av == nil:
+				cmp = 1
+			case bv == nil:
+				cmp = -1
+			default:
+				cmp = compare(av, bv)
+			}
+
+			if t.desc {
+				cmp = -cmp
+			}
+			if cmp != 0 {
+				return cmp
+			}
+		}
+
+		return 0
+	})
+
+	return sorted
+}
+
+func compare(a, b any) int {
+	if x, ok := a.(int); ok {
+		return x - b.(int)
+	}
+
+	return strings.Compare(a.(string), b.(string))
+}
+
+// evalCondition evaluates the subset of SQL that after writes against a row:
+// AND, OR, parentheses, IS [NOT] NULL, =, <, > and row comparisons. A
+// comparison with a missing value is unknown, which only true overrides in OR
+// and only false in AND, and the row is selected when the result is true.
+func evalCondition(clause string, args []any, r row) bool {
+	e := &evaluator{tokens: tokenize(clause), args: args, row: r}
+	result := e.or()
+	if e.pos != len(e.tokens) {
+		panic("unexpected " + e.tokens[e.pos] + " in " + clause)
+	}
+
+	return result == yes
+}
+
+type truth int
+
+const (
+	no truth = iota
+	unknown
+	yes
+)
+
+type evaluator struct {
+	tokens []string
+	pos    int
+	args   []any
+	row    row
+}
+
+func tokenize(clause string) []string {
+	clause = strings.NewReplacer("(", " ( ", ")", " ) ", ",", " , ").Replace(clause)
+
+	return strings.Fields(clause)
+}
+
+func (e *evaluator) next() string {
+	e.pos++
+
+	return e.tokens[e.pos-1]
+}
+
+func (e *evaluator) peek(token string) bool {
+	return e.pos < len(e.tokens) && e.tokens[e.pos] == token
+}
+
+func (e *evaluator) or() truth {
+	result := e.and()
+	for e.peek("OR") {
+		e.next()
+		result = max(result, e.and())
+	}
+
+	return result
+}
+
+func (e *evaluator) and() truth {
+	result := e.primary()
+	for e.peek("AND") {
+		e.next()
+		result = min(result, e.primary())
+	}
+
+	return result
+}
+
+func (e *evaluator) primary() truth {
+	if !e.peek("(") {
+		return e.comparison()
+	}
+
+	if e.isTuple() {
+		return e.rowComparison()
+	}
+
+	e.next()
+	result := e.or()
+	if e.next() != ")" {
+		panic("unbalanced parentheses")
+	}
+
+	return result
+}
+
+// isTuple reports whether the parenthesis at pos opens a list of values,
+// which a comparison operator follows even when it has a single value
+func (e *evaluator) isTuple() bool {
+	depth := 0
+	for i, token := range e.tokens[e.pos:] {
+		switch token {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				after := e.pos + i + 1
+				return after < len(e.tokens) && strings.Contains("<>=", e.tokens[after])
+			}
+		case ",":
+			if depth == 1 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (e *evaluator) tuple() []any {
+	e.next()
+	var values []any
+	for {
+		values = append(values, e.value(e.next()))
+		if e.next() == ")" {
+			return values
+		}
+	}
+}
+
+func (e *evaluator) rowComparison() truth {
+	left := e.tuple()
+	op := e.next()
+	right := e.tuple()
+
+	for i := range left {
+		if left[i] == nil || right[i] == nil {
+			return unknown
+		}
+		if cmp := compare(left[i], right[i]); cmp != 0 {
+			return test(op, cmp)
+		}
+	}
+
+	return test(op, 0)
+}
+
+func (e *evaluator) comparison() truth {
+	left := e.value(e.next())
+
+	op := e.next()
+	if op == "IS" {
+		isNull := !e.peek("NOT")
+		if !isNull {
+			e.next()
+		}
+		if e.next() != "NULL" {
+			panic("expected NULL")
+		}
+
+		if (left == nil) == isNull {
+			return yes
+		}
+		return no
+	}
+
+	right := e.value(e.next())
+	if left == nil || right == nil {
+		return unknown
+	}
+
+	return test(op, compare(left, right))
+}
+
+// value reads a placeholder or a column of the row
+func (e *evaluator) value(token string) any {
+	if n, ok := strings.CutPrefix(token, "$"); ok {
+		i, _ := strconv.Atoi(n)
+		return e.args[i-1]
+	}
+
+	v, ok := e.row[token]
+	if !ok {
+		panic("unknown column " + token)
+	}
+
+	return v
+}
+
+func test(op string, cmp int) truth {
+	var ok bool
+	switch op {
+	case "=":
+		ok = cmp == 0
+	case "<":
+		ok = cmp < 0
+	case ">":
+		ok = cmp > 0
+	default:
+		panic("unknown operator " + op)
+	}
+
+	if ok {
+		return yes
+	}
+	return no
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/agl/fio/internal/domain/interfaces"
+	"github.com/agl/fio/internal/presentation/extractors"
+	. "github.com/agl/fio/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler serves operational endpoints of the enrichment pipeline
+type AdminHandler struct {
+	cache    *extractors.NameCache
+	breakers extractors.Breakers
+	jobs     interfaces.JobRunner
+}
+
+func NewAdminHandler(cache *extractors.NameCache, breakers extractors.Breakers, jobs interfaces.JobRunner) *AdminHandler {
+	return &AdminHandler{cache: cache, breakers: breakers, jobs: jobs}
+}
+
+func (a *AdminHandler) register(r *gin.Engine) {
+	admin := r.Group("/admin")
+
+	admin.GET("/enrichment-cache", a.getCacheStats)
+	admin.DELETE("/enrichment-cache/:name", a.purgeCacheEntry)
+	admin.GET("/enrichment-breakers", a.getBreakers)
+	admin.GET("/jobs", a.getJobs)
+	admin.POST("/jobs/:name/run", a.runJob)
+}
+
+// getCacheStats godoc
+// @Summary Get enrichment cache statistics
+// @Description Get hit/miss counters and the number of cached entries
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} extractors.CacheStats
+// @Router /admin/enrichment-cache [get]
+func (a *AdminHandler) getCacheStats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, a.cache.Stats())
+}
+
+// purgeCacheEntry godoc
+// @Summary Purge cached enrichment for a name
+// @Description Remove the cached results of every provider for the given first name
+// @Tags Admin
+// @Produce json,application/problem+json
+// @Param name path string true "First name"
+// @Success 200 {object} map[string]string
+// @Failure 500 {object} responses.Problem
+// @Router /admin/enrichment-cache/{name} [delete]
+func (a *AdminHandler) purgeCacheEntry(ctx *gin.Context) {
+	name := ctx.Param("name")
+
+	Log.Debug("Received request to purge enrichment cache", "name", name)
+
+	if err := a.cache.Purge(ctx.Request.Context(), name); err != nil {
+		Log.Info("Failed to purge enrichment cache", "name", name, "error", err)
+
+		ctx.Error(err)
+
+		return
+	}
+
+	Log.Info("Enrichment cache purged", "name", name)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Enrichment cache purged successfully",
+		"name":    name,
+	})
+}
+
+// getBreakers godoc
+// @Summary Get enrichment circuit breakers
+// @Description Get the circuit breaker state of every enrichment provider
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} extractors.BreakerStatus
+// @Router /admin/enrichment-breakers [get]
+func (a *AdminHandler) getBreakers(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, a.breakers.Status())
+}
+
+// getJobs godoc
+// @Summary Get background jobs
+// @Description Get the schedule, state and progress of the current or last run of every background job
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} entities.JobStatus
+// @Router /admin/jobs [get]
+func (a *AdminHandler) getJobs(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, a.jobs.Statuses())
+}
+
+// runJob godoc
+// @Summary Run a background job now
+// @Description Start a run of the job without waiting for its schedule
+// @Tags Admin
+// @Produce json,application/problem+json
+// @Param name path string true "Job name"
+// @Success 202 {object} map[string]string
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Router /admin/jobs/{name}/run [post]
+func (a *AdminHandler) runJob(ctx *gin.Context) {
+	name := ctx.Param("name")
+
+	Log.Debug("Received request to run job", "job", name)
+
+	if err := a.jobs.Trigger(name); err != nil {
+		Log.Info("Failed to trigger job", "job", name, "error", err)
+
+		ctx.Error(err)
+
+		return
+	}
+
+	Log.Info("Job triggered", "job", name)
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "Job started",
+		"name":    name,
+	})
+}
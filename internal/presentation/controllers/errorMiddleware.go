@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/agl/fio/internal/domain/apperrors"
+	"github.com/agl/fio/internal/domain/entities/responses"
+	"github.com/agl/fio/internal/presentation/extractors"
+	. "github.com/agl/fio/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// problemContentType is the media type of RFC 7807 error responses
+const problemContentType = "application/problem+json"
+
+// problemType describes the responses for one kind of error
+type problemType struct {
+	kind   error
+	status int
+	uri    string
+	title  string
+}
+
+// problemTypes maps every error kind to its response, anything else is internal
+var problemTypes = []problemType{
+	{apperrors.ErrBadRequest, http.StatusBadRequest, "/problems/bad-request", "Bad Request"},
+	{apperrors.ErrValidation, http.StatusUnprocessableEntity, "/problems/validation", "Validation Failed"},
+	{apperrors.ErrNotFound, http.StatusNotFound, "/problems/not-found", "Not Found"},
+	{apperrors.ErrConflict, http.StatusConflict, "/problems/conflict", "Conflict"},
+	{apperrors.ErrUpstream, http.StatusBadGateway, "/problems/upstream", "Upstream Failure"},
+	{apperrors.ErrUnavailable, http.StatusServiceUnavailable, "/problems/unavailable", "Service Unavailable"},
+}
+
+var internalProblem = problemType{nil, http.StatusInternalServerError, "/problems/internal", "Internal Server Error"}
+
+// ErrorHandler renders the last error a handler attached with ctx.Error as
+// an RFC 7807 problem. Only the client-safe part of domain errors is sent,
+// other errors are logged and reported as internal.
+func ErrorHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+
+		err := ctx.Errors.Last().Err
+
+		pt := internalProblem
+		for _, t := range problemTypes {
+			if errors.Is(err, t.kind) {
+				pt = t
+
+				break
+			}
+		}
+
+		problem := responses.Problem{
+			Type:     pt.uri,
+			Title:    pt.title,
+			Status:   pt.status,
+			Instance: ctx.Request.URL.RequestURI(),
+		}
+
+		var appErr *apperrors.Error
+		if errors.As(err, &appErr) {
+			problem.Detail = apperrors.Message(appErr)
+			for _, field := range appErr.Fields {
+				problem.Errors = append(problem.Errors, responses.FieldError{Field: field.Field, Message: field.Message})
+			}
+
+			if appErr.RetryAfter > 0 {
+				retryAfter := int(math.Ceil(appErr.RetryAfter.Seconds()))
+				ctx.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+			}
+		}
+
+		if pt.status == http.StatusInternalServerError {
+			Log.Error("Request failed", "method", ctx.Request.Method, "path", ctx.FullPath(), "error", err)
+		}
+
+		ctx.Header("Content-Type", problemContentType)
+		ctx.JSON(pt.status, problem)
+	}
+}
+
+// invalidInput reports a request body that could not be decoded
+func invalidInput(err error) error {
+	return apperrors.Wrap(apperrors.ErrBadRequest, "Invalid input", err).WithDetails(err.Error())
+}
+
+// enrichmentError converts a failed enrichment into a domain error
+func enrichmentError(err error) error {
+	var rateLimited *extractors.RateLimitError
+	if errors.As(err, &rateLimited) {
+		return apperrors.Wrap(apperrors.ErrUnavailable, "Enrichment service is rate limited", err).WithRetryAfter(rateLimited.RetryAfter)
+	}
+
+	if errors.Is(err, extractors.ErrNoProvider) {
+		return apperrors.Wrap(apperrors.ErrUnavailable, "Enrichment is not configured for every field", err)
+	}
+
+	// providers that answered but do not know the name are not failing
+	var enrichErr *extractors.EnrichmentError
+	if errors.As(err, &enrichErr) && onlyUnknown(enrichErr) {
+		unknown := apperrors.Wrap(apperrors.ErrValidation, "The name could not be enriched", err)
+		for _, field := range enrichErr.UnknownFields() {
+			unknown.WithField(field, "is unknown for this name")
+		}
+
+		return unknown
+	}
+
+	return apperrors.Upstream("Failed to retrieve extra person data", err)
+}
+
+func onlyUnknown(err *extractors.EnrichmentError) bool {
+	for _, failure := range err.Failures {
+		if !errors.Is(failure.Err, extractors.ErrNoData) {
+			return false
+		}
+	}
+
+	return len(err.Failures) > 0
+}
@@ -3,23 +3,33 @@ package controllers
 import (
 	"net/http"
 	"os"
+	"strconv"
 
+	"github.com/agl/fio/internal/domain/apperrors"
 	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/entities/responses"
 	"github.com/agl/fio/internal/domain/interfaces"
 	"github.com/agl/fio/internal/presentation/extractors"
 	. "github.com/agl/fio/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
+// maxImportSize limits the number of people in one import request
+const maxImportSize = 1000
+
 type PersonHandler struct {
-	PORT    string
-	service interfaces.PersonService
+	PORT     string
+	policy   extractors.Policy
+	service  interfaces.PersonService
+	enricher interfaces.Enricher
+	admin    *AdminHandler
 }
 
-func NewPersonHandler(service interfaces.PersonService) *PersonHandler {
+func NewPersonHandler(service interfaces.PersonService, enricher interfaces.Enricher, admin *AdminHandler) *PersonHandler {
 	PORT := os.Getenv("PORT")
+	policy := extractors.ParsePolicy(os.Getenv("ENRICHMENT_POLICY"))
 
-	return &PersonHandler{PORT: PORT, service: service}
+	return &PersonHandler{PORT: PORT, policy: policy, service: service, enricher: enricher, admin: admin}
 }
 
 // @title People Library API
@@ -29,15 +39,25 @@ func NewPersonHandler(service interfaces.PersonService) *PersonHandler {
 // @BasePath /
 func (p *PersonHandler) StartApi() {
 	r := gin.Default()
+	r.Use(ErrorHandler())
 
 	r.GET("/person/:id", p.getPerson)
 	r.GET("/person/filter", p.getPerson_Filter)
+	r.GET("/person/search", p.searchPeople)
 
 	r.DELETE("/person/:id", p.deletePerson)
 
 	r.PATCH("/person/:id", p.updatePerson)
 
+	r.PUT("/person/:id/locks/:field", p.lockField)
+	r.DELETE("/person/:id/locks/:field", p.unlockField)
+
 	r.POST("/person", p.createPerson)
+	r.POST("/person/import", p.importPeople)
+
+	if p.admin != nil {
+		p.admin.register(r)
+	}
 
 	r.Run(":" + p.PORT)
 }
@@ -46,38 +66,35 @@ func (p *PersonHandler) StartApi() {
 // updatePerson godoc
 // @Summary Partially update an existing person
 // @Description Update person's information by ID. Only provided fields will be updated.
+// @Description Age, gender and nationality set here are locked against automatic enrichment.
 // @Tags People
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
 // @Param id path int true "Person ID"
-// @Param person body entities.Person true "Person object with fields to update"
+// @Param person body entities.PersonPatch true "Person object with fields to update"
 // @Success 200 {object} map[string]responses.ResponseMessage
-// @Failure 400 {object} map[string]string{error,details}
-// @Failure 409 {object} map[string]string{error,details}
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 422 {object} responses.Problem
 // @Router /person/{id} [patch]
 func (p *PersonHandler) updatePerson(ctx *gin.Context) {
 	id := ctx.Param("id")
 
 	Log.Debug("Received request to update person", "id", id)
 
-	var person entities.Person
-	if err := ctx.ShouldBindJSON(&person); err != nil {
+	var patch entities.PersonPatch
+	if err := ctx.ShouldBindJSON(&patch); err != nil {
 		Log.Info("Failed to bind JSON for updating person", "id", id, "error", err)
 
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid input",
-			"details": err.Error(),
-		})
+		ctx.Error(invalidInput(err))
 
 		return
 	}
 
-	if err := p.service.UpdatePersonByID(person, id); err != nil {
+	if err := p.service.UpdatePersonByID(ctx.Request.Context(), patch, id); err != nil {
 		Log.Info("Failed to update person in database", "id", id, "error", err)
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Failed to update person",
-			"details": err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
@@ -89,16 +106,75 @@ func (p *PersonHandler) updatePerson(ctx *gin.Context) {
 	})
 }
 
+// lockField godoc
+// @Summary Lock a field against enrichment
+// @Description Protect age, gender or nationality of a person from being overwritten by enrichment
+// @Tags People
+// @Produce json,application/problem+json
+// @Param id path int true "Person ID"
+// @Param field path string true "Field to lock" Enums(age, gender, nationality)
+// @Success 200 {object} map[string]responses.ResponseMessage
+// @Failure 404 {object} responses.Problem
+// @Failure 422 {object} responses.Problem
+// @Router /person/{id}/locks/{field} [put]
+func (p *PersonHandler) lockField(ctx *gin.Context) {
+	p.setFieldLock(ctx, true)
+}
+
+// unlockField godoc
+// @Summary Unlock a field
+// @Description Let enrichment manage age, gender or nationality of a person again
+// @Tags People
+// @Produce json,application/problem+json
+// @Param id path int true "Person ID"
+// @Param field path string true "Field to unlock" Enums(age, gender, nationality)
+// @Success 200 {object} map[string]responses.ResponseMessage
+// @Failure 404 {object} responses.Problem
+// @Failure 422 {object} responses.Problem
+// @Router /person/{id}/locks/{field} [delete]
+func (p *PersonHandler) unlockField(ctx *gin.Context) {
+	p.setFieldLock(ctx, false)
+}
+
+func (p *PersonHandler) setFieldLock(ctx *gin.Context, locked bool) {
+	id := ctx.Param("id")
+	field := ctx.Param("field")
+
+	Log.Debug("Received request to set field lock", "id", id, "field", field, "locked", locked)
+
+	fields, err := p.service.SetFieldLock(ctx.Request.Context(), id, field, locked)
+	if err != nil {
+		Log.Info("Failed to set field lock", "id", id, "field", field, "error", err)
+
+		ctx.Error(err)
+
+		return
+	}
+
+	Log.Info("Field lock set", "id", id, "field", field, "locked", locked)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Field lock updated successfully",
+		"id":      id,
+		"locked":  fields,
+	})
+}
+
 // createPerson godoc
 // @Summary Create a new person
-// @Description Create a person entity and enrich it with age, gender, and nationality by name
+// @Description Create a person entity and enrich it with age, gender, and nationality by name.
+// @Description With ENRICHMENT_POLICY=best-effort fields that could not be enriched are listed in "pending" and filled in later.
 // @Tags People
 // @Accept json
-// @Produce json
-// @Param person body entities.Person true "Person object"
+// @Produce json,application/problem+json
+// @Param person body entities.ReceivedPerson true "Person object, country_id optionally localizes age and gender prediction"
 // @Success 201 {object} map[string]responses.ResponseMessage
-// @Failure 400 {object} map[string]string{error,details}
-// @Failure 409 {object} map[string]string{error,details}
+// @Failure 400 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 422 {object} responses.Problem
+// @Failure 502 {object} responses.Problem
+// @Failure 503 {object} responses.Problem
+// @Header 503 {integer} Retry-After "Seconds until the enrichment quota is renewed"
 // @Router /person [post]
 func (p *PersonHandler) createPerson(ctx *gin.Context) {
 	var received_person entities.ReceivedPerson
@@ -108,69 +184,150 @@ func (p *PersonHandler) createPerson(ctx *gin.Context) {
 	if err := ctx.ShouldBindJSON(&received_person); err != nil {
 		Log.Info("Failed to bind JSON", "error", err)
 
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid input",
-			"details": err.Error(),
-		})
+		ctx.Error(invalidInput(err))
 
 		return
 	}
 
-	person, err := extractors.GetExtraUserInfoByName(received_person.Name, received_person)
+	received_person, err := p.service.ValidateReceivedPerson(ctx.Request.Context(), received_person)
+	if err != nil {
+		ctx.Error(err)
+
+		return
+	}
+
+	person, err := extractors.GetExtraUserInfoByName(ctx.Request.Context(), p.enricher, p.policy, received_person)
 
 	if err != nil {
 		Log.Info("Failed to retrieve extra person data", "name", person.Name, "error", err)
 
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Failed to retrieve extra person data",
-			"details": err.Error(),
-		})
+		ctx.Error(enrichmentError(err))
 
 		return
 	}
 
 	Log.Debug("Person enriched with extra data", "name", person.Name, "age", person.Age, "gender", person.Gender, "nationality", person.Nationality)
 
-	id, err := p.service.CreatePerson(person)
+	id, err := p.service.CreatePerson(ctx.Request.Context(), person)
 	if err != nil {
 		Log.Info("Failed to create person", "name", person.Name, "error", err)
 
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Failed to create person",
-			"details": err.Error(),
-		})
+		ctx.Error(err)
 
 		return
 	}
 
-	Log.Info("Person created successfully", "id", id)
+	Log.Info("Person created successfully", "id", id, "pending", person.Pending)
 
-	ctx.JSON(http.StatusCreated, gin.H{
+	response := gin.H{
 		"message": "Person created successfully",
 		"id":      id,
+	}
+	if len(person.Pending) > 0 {
+		response["pending"] = person.Pending
+	}
+
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// importPeople godoc
+// @Summary Import many people
+// @Description Create many people at once. Names are enriched with batch requests to the enrichment APIs.
+// @Description The result of every person is reported separately, in the order of the request.
+// @Tags People
+// @Accept json
+// @Produce json,application/problem+json
+// @Param people body []entities.ReceivedPerson true "People to import"
+// @Success 200 {object} map[string][]responses.ImportResult
+// @Failure 400 {object} responses.Problem
+// @Router /person/import [post]
+func (p *PersonHandler) importPeople(ctx *gin.Context) {
+	var received []entities.ReceivedPerson
+
+	if err := ctx.ShouldBindJSON(&received); err != nil {
+		Log.Info("Failed to bind JSON for import", "error", err)
+
+		ctx.Error(invalidInput(err))
+
+		return
+	}
+
+	if len(received) == 0 || len(received) > maxImportSize {
+		ctx.Error(apperrors.New(apperrors.ErrBadRequest, "Invalid input").WithDetails("expected between 1 and " + strconv.Itoa(maxImportSize) + " people"))
+
+		return
+	}
+
+	Log.Debug("Received request to import people", "count", len(received))
+
+	results := make([]responses.ImportResult, len(received))
+	var valid []entities.ReceivedPerson
+	var indexes []int
+	for i, r := range received {
+		results[i] = responses.ImportResult{Index: i}
+
+		normalized, err := p.service.ValidateReceivedPerson(ctx.Request.Context(), r)
+		if err != nil {
+			results[i].Error = apperrors.Message(err)
+
+			continue
+		}
+
+		valid = append(valid, normalized)
+		indexes = append(indexes, i)
+	}
+
+	people, errs := extractors.GetExtraUserInfoByNames(ctx.Request.Context(), p.enricher, p.policy, valid)
+
+	created := 0
+	for j, person := range people {
+		i := indexes[j]
+
+		if errs[j] != nil {
+			results[i].Error = apperrors.Message(enrichmentError(errs[j]))
+
+			continue
+		}
+
+		id, err := p.service.CreatePerson(ctx.Request.Context(), person)
+		if err != nil {
+			results[i].Error = apperrors.Message(err)
+
+			continue
+		}
+
+		results[i].ID = id
+		results[i].Pending = person.Pending
+		created++
+	}
+
+	Log.Info("People imported", "requested", len(received), "created", created)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Import finished",
+		"created": created,
+		"data":    results,
 	})
 }
 
 // getPerson godoc
 // @Summary Get person by ID
-// @Description Get a single person by their ID
+// @Description Get a single person by their ID, including the provenance of enriched fields
 // @Tags People
-// @Produce json
+// @Produce json,application/problem+json
 // @Param id path int true "Person ID"
 // @Success 200 {object} map[string]responses.FoundPerson
-// @Failure 400 {object} map[string]string{error,details}
+// @Failure 404 {object} responses.Problem
+// @Failure 422 {object} responses.Problem
 // @Router /person/{id} [get]
 func (p *PersonHandler) getPerson(ctx *gin.Context) {
 	id := ctx.Param("id")
 	Log.Debug("Received request for getPerson", "id", id)
 
-	person, err := p.service.GetPersonByID(id)
+	person, err := p.service.GetPersonByID(ctx.Request.Context(), id)
 	if err != nil {
 		Log.Info("Failed to get person by ID", "id", id, "error", err)
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Failed to get person by ID",
-			"details": err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
@@ -183,47 +340,106 @@ func (p *PersonHandler) getPerson(ctx *gin.Context) {
 
 // getPerson_Filter godoc
 // @Summary Get people by filter
-// @Description Get a list of people filtered by parameters with pagination
+// @Description Get a list of people filtered by parameters. Pages are read with the after cursor: pass the next_cursor
+// @Description of a page to get the next one, it is absent on the last page.
+// @Description Every field also takes operators as field[op]=value: eq and ne, gt, gte, lt and lte for age,
+// @Description in with a comma separated list (nationality[in]=RU,UA,BY) and null=true|false for a missing value.
+// @Description q takes comparisons (: or =, !=, >, >=, <, <=, ~ for a substring of a name, null for a missing value)
+// @Description combined with AND, OR, NOT and parentheses; a malformed q is rejected with 400.
 // @Tags People
-// @Produce json
+// @Produce json,application/problem+json
 // @Param name query string false "Name to filter by"
 // @Param surname query string false "Surname to filter by"
 // @Param patronymic query string false "Patronymic to filter by"
 // @Param age query int false "Age to filter by"
+// @Param age[gte] query int false "Minimum age"
+// @Param age[lt] query int false "Age below"
 // @Param gender query string false "Gender to filter by"
+// @Param gender[ne] query string false "Gender to exclude"
 // @Param nationality query string false "Nationality to filter by"
-// @Param page query int false "Page number"
-// @Param limit query int false "Number of results per page"
-// @Success 200 {object} map[string]responses.FoundPerson
-// @Failure 400 {object} map[string]string{error,details}
+// @Param nationality[in] query string false "Comma separated nationalities"
+// @Param patronymic[null] query bool false "Without (true) or with (false) a patronymic"
+// @Param q query string false "Query expression, e.g. age>=30 AND (nationality:RU OR nationality:KZ) AND surname~ov"
+// @Param min_confidence query number false "Minimum enrichment probability (0-1) of every enriched field, people without a recorded gender or nationality probability do not match"
+// @Param translit query bool false "Match names across Cyrillic and Latin spellings (GOST 7.79, ICAO 9303)"
+// @Param sort query string false "Comma separated sort fields (id, name, surname, patronymic, age, gender, nationality), - for descending, e.g. surname,-age"
+// @Param after query string false "Cursor of the page, next_cursor of the previous one"
+// @Param limit query int false "Number of results per page, 20 by default, larger values are lowered to 100"
+// @Success 200 {object} responses.PeoplePage
+// @Failure 400 {object} responses.Problem
+// @Failure 422 {object} responses.Problem
 // @Router /person/filter [get]
 func (p *PersonHandler) getPerson_Filter(ctx *gin.Context) {
-	page := ctx.Query("page")
+	after := ctx.Query("after")
 	limit := ctx.Query("limit")
 
-	name := ctx.DefaultQuery("name", "")
-	surname := ctx.DefaultQuery("surname", "")
-	patronymic := ctx.DefaultQuery("patronymic", "")
-	age := ctx.DefaultQuery("age", "0")
-	gender := ctx.DefaultQuery("gender", "")
-	nationality := ctx.DefaultQuery("nationality", "")
+	params := entities.FilterParams{}
+	for _, field := range entities.FilterFields {
+		ops := ctx.QueryMap(field)
+		if value, ok := ctx.GetQuery(field); ok {
+			ops[entities.OpEq] = value
+		}
+		if len(ops) > 0 {
+			params[field] = ops
+		}
+	}
+	q := ctx.Query("q")
+	minConfidence := ctx.Query("min_confidence")
+	translit := ctx.Query("translit")
+	sort := ctx.Query("sort")
+
+	Log.Debug("Received request for getPerson_Filter", "filter", params, "q", q, "sort", sort, "after", after, "limit", limit)
+
+	people, next, err := p.service.GetPeopleByFilter(ctx.Request.Context(), params, q, minConfidence, translit, sort, after, limit)
+	if err != nil {
+		Log.Info("Failed to get people by filter", "filter", params, "error", err)
+		ctx.Error(err)
+		return
+	}
+
+	Log.Info("Successfully fetched people by filter", "filter", params, "count", len(people))
+	ctx.JSON(http.StatusOK, responses.PeoplePage{
+		Message:    "People received successfully",
+		Data:       people,
+		NextCursor: next,
+	})
+}
+
+// searchPeople godoc
+// @Summary Search people by name
+// @Description Search over name, surname and patronymic. The fuzzy mode tolerates typos and ranks by trigram similarity.
+// @Description The fulltext mode matches every word of q as a prefix of a name part, ranks by ts_rank and highlights the matches.
+// @Tags People
+// @Produce json,application/problem+json
+// @Param q query string true "Name, surname or patronymic, possibly misspelled; several words in fulltext mode"
+// @Param mode query string false "Search mode, fuzzy by default" Enums(fuzzy, fulltext)
+// @Param threshold query number false "Minimum similarity (0-1) in fuzzy mode, 0.3 by default"
+// @Param limit query int false "Maximum number of results, 20 by default, at most 100"
+// @Success 200 {object} map[string][]entities.SearchHit
+// @Failure 422 {object} responses.Problem
+// @Router /person/search [get]
+func (p *PersonHandler) searchPeople(ctx *gin.Context) {
+	q := ctx.Query("q")
+	mode := ctx.Query("mode")
+	threshold := ctx.Query("threshold")
+	limit := ctx.Query("limit")
 
-	Log.Debug("Received request for getPerson_Age", "age", age, "page", page, "limit", limit)
+	Log.Debug("Received request to search people", "q", q, "mode", mode, "threshold", threshold, "limit", limit)
 
-	people, err := p.service.GetPeopleByFilter(name, surname, age, gender, nationality, page, limit, &patronymic)
+	hits, err := p.service.SearchPeople(ctx.Request.Context(), q, mode, threshold, limit)
 	if err != nil {
-		Log.Info("Failed to get people by age", "age", age, "error", err)
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Failed to get people by age",
-			"details": err.Error(),
-		})
+		Log.Info("Failed to search people", "q", q, "error", err)
+
+		ctx.Error(err)
+
 		return
 	}
 
-	Log.Info("Successfully fetched people by age", "age", age, "count", len(people))
+	Log.Info("Successfully searched people", "q", q, "count", len(hits))
+
 	ctx.JSON(http.StatusOK, gin.H{
-		"message": "People received successfully",
-		"data":    people,
+		"message": "People found successfully",
+		"data":    hits,
 	})
 }
 
@@ -231,24 +447,22 @@ func (p *PersonHandler) getPerson_Filter(ctx *gin.Context) {
 // @Summary Delete person by ID
 // @Description Delete a single person from the database by their ID
 // @Tags People
-// @Produce json
+// @Produce json,application/problem+json
 // @Param id path int true "Person ID"
 // @Success 200 {object} map[string]responses.ResponseMessage
-// @Failure 409 {object} map[string]string{error,details}
+// @Failure 404 {object} responses.Problem
+// @Failure 422 {object} responses.Problem
 // @Router /person/{id} [delete]
 func (p *PersonHandler) deletePerson(ctx *gin.Context) {
 	id := ctx.Param("id")
 
 	Log.Debug("Received request to delete person", "id", id)
 
-	err := p.service.DeletePersonByID(id)
+	err := p.service.DeletePersonByID(ctx.Request.Context(), id)
 	if err != nil {
 		Log.Info("Failed to delete person", "id", id, "error", err)
 
-		ctx.JSON(http.StatusConflict, gin.H{
-			"error":   "Failed to delete person",
-			"details": err.Error(),
-		})
+		ctx.Error(err)
 
 		return
 	}
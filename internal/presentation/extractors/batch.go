@@ -0,0 +1,79 @@
+package extractors
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/interfaces"
+)
+
+// MaxBatchSize is the number of names the public APIs accept in one request
+const MaxBatchSize = 10
+
+// enrichBatch queries api for people in groups of MaxBatchSize names and
+// applies each result to its person. With useHint people are also grouped by
+// country hint, since a request carries a single country_id. The returned
+// errors are aligned with people.
+func enrichBatch[T any](ctx context.Context, api *upstream, people []*entities.Person, useHint bool, apply func(*entities.Person, T) error) []error {
+	errs := make([]error, len(people))
+
+	byCountry := map[string][]int{}
+	var countries []string
+	for i, person := range people {
+		country := ""
+		if useHint {
+			country = person.CountryHint
+		}
+
+		if _, ok := byCountry[country]; !ok {
+			countries = append(countries, country)
+		}
+		byCountry[country] = append(byCountry[country], i)
+	}
+
+	for _, country := range countries {
+		indexes := byCountry[country]
+
+		for start := 0; start < len(indexes); start += MaxBatchSize {
+			group := indexes[start:min(start+MaxBatchSize, len(indexes))]
+
+			names := make([]string, len(group))
+			for i, idx := range group {
+				names[i] = people[idx].Name
+			}
+
+			var results []T
+			err := api.getBatch(ctx, names, country, &results)
+			if err == nil && len(results) != len(group) {
+				err = fmt.Errorf("%s: expected %d results, got %d", api.provider, len(group), len(results))
+			}
+
+			for i, idx := range group {
+				if err != nil {
+					errs[idx] = err
+
+					continue
+				}
+
+				errs[idx] = apply(people[idx], results[i])
+			}
+		}
+	}
+
+	return errs
+}
+
+// enrichEach enriches people with provider, in batches when it supports them
+func enrichEach(ctx context.Context, provider interfaces.Enricher, people []*entities.Person) []error {
+	if batch, ok := provider.(interfaces.BatchEnricher); ok {
+		return batch.EnrichBatch(ctx, people)
+	}
+
+	errs := make([]error, len(people))
+	for i, person := range people {
+		errs[i] = provider.Enrich(ctx, person)
+	}
+
+	return errs
+}
@@ -0,0 +1,214 @@
+package extractors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/interfaces"
+	. "github.com/agl/fio/pkg/logger"
+)
+
+// ErrCircuitOpen is returned without calling the provider while its breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type BreakerState string
+
+const (
+	StateClosed   BreakerState = "closed"
+	StateOpen     BreakerState = "open"
+	StateHalfOpen BreakerState = "half-open"
+)
+
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// BreakerStatus is a snapshot of a breaker for diagnostics
+type BreakerStatus struct {
+	Provider            string       `json:"provider"`
+	Field               string       `json:"field"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	LastError           string       `json:"last_error,omitempty"`
+}
+
+// BreakerProvider wraps a provider with a circuit breaker. After threshold
+// consecutive failures the breaker opens and calls fail fast with
+// ErrCircuitOpen. Once the cooldown has passed a single trial call is let
+// through (half-open): success closes the breaker, failure opens it again.
+type BreakerProvider struct {
+	interfaces.Provider
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	lastError string
+	trial     bool
+}
+
+func NewBreakerProvider(provider interfaces.Provider, threshold int, cooldown time.Duration) *BreakerProvider {
+	if threshold <= 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+
+	return &BreakerProvider{Provider: provider, threshold: threshold, cooldown: cooldown, state: StateClosed}
+}
+
+func (b *BreakerProvider) Enrich(ctx context.Context, person *entities.Person) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	err := b.Provider.Enrich(ctx, person)
+	b.record(err)
+
+	return err
+}
+
+// EnrichBatch counts the whole batch as one call: it fails when any person
+// failed in a way that says the provider is unhealthy
+func (b *BreakerProvider) EnrichBatch(ctx context.Context, people []*entities.Person) []error {
+	if err := b.allow(); err != nil {
+		errs := make([]error, len(people))
+		for i := range errs {
+			errs[i] = err
+		}
+
+		return errs
+	}
+
+	errs := enrichEach(ctx, b.Provider, people)
+
+	var failure error
+	for _, err := range errs {
+		if err != nil && countsAsFailure(err) {
+			failure = err
+
+			break
+		}
+	}
+	b.record(failure)
+
+	return errs
+}
+
+func (b *BreakerProvider) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{
+		Provider:            b.Name(),
+		Field:               b.Field(),
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+
+	return status
+}
+
+func (b *BreakerProvider) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return fmt.Errorf("%s: %w", b.Name(), ErrCircuitOpen)
+		}
+
+		b.setState(StateHalfOpen)
+		b.trial = true
+
+		return nil
+	case StateHalfOpen:
+		if b.trial {
+			return fmt.Errorf("%s: %w", b.Name(), ErrCircuitOpen)
+		}
+
+		b.trial = true
+	}
+
+	return nil
+}
+
+func (b *BreakerProvider) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+
+	if err == nil {
+		b.failures = 0
+		b.lastError = ""
+		if b.state != StateClosed {
+			b.setState(StateClosed)
+		}
+
+		return
+	}
+
+	if !countsAsFailure(err) {
+		return
+	}
+
+	b.failures++
+	b.lastError = err.Error()
+
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		if b.state != StateOpen {
+			b.setState(StateOpen)
+		}
+	}
+}
+
+// countsAsFailure tells whether err says something about the health of the
+// provider. Cancelled requests, rate limits (already failing fast) and a
+// provider that answered but has no data for the name are not counted.
+func countsAsFailure(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrUpstreamRateLimited) || errors.Is(err, ErrNoData) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode < 500 {
+		return false
+	}
+
+	return true
+}
+
+func (b *BreakerProvider) setState(state BreakerState) {
+	Log.Warn("Circuit breaker state changed", "provider", b.Name(), "from", b.state, "to", state, "failures", b.failures)
+
+	b.state = state
+}
+
+// Breakers collects the breakers of all providers for the diagnostics endpoint
+type Breakers []*BreakerProvider
+
+func (b Breakers) Status() []BreakerStatus {
+	statuses := make([]BreakerStatus, 0, len(b))
+	for _, breaker := range b {
+		statuses = append(statuses, breaker.Status())
+	}
+
+	return statuses
+}
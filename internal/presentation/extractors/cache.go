@@ -0,0 +1,281 @@
+package extractors
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/interfaces"
+	. "github.com/agl/fio/pkg/logger"
+)
+
+const (
+	DefaultCacheSize = 10000
+	DefaultCacheTTL  = 30 * 24 * time.Hour
+)
+
+// CacheStats reports the effectiveness of the enrichment cache
+type CacheStats struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Entries int   `json:"entries"`
+}
+
+type cacheEntry struct {
+	provider  string
+	name      string
+	person    entities.Person
+	expiresAt time.Time
+}
+
+// NameCache is an in-memory LRU with TTL for enrichment results, optionally
+// backed by a persistent store that survives restarts.
+type NameCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	items    map[string]*list.Element
+	order    *list.List
+	store    interfaces.EnrichmentCacheStore
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewNameCache creates a cache. store may be nil to keep the cache in memory only.
+func NewNameCache(capacity int, ttl time.Duration, store interfaces.EnrichmentCacheStore) *NameCache {
+	if capacity <= 0 {
+		capacity = DefaultCacheSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+
+	return &NameCache{
+		capacity: capacity,
+		ttl:      ttl,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		store:    store,
+	}
+}
+
+// NormalizeName builds the cache key for a first name
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func (c *NameCache) Get(ctx context.Context, provider, name string) (entities.Person, bool) {
+	name = NormalizeName(name)
+
+	if person, ok := c.getMemory(provider, name); ok {
+		c.hits.Add(1)
+
+		return person, true
+	}
+
+	if c.store != nil {
+		person, ok, err := c.store.Get(ctx, provider, name)
+		if err != nil {
+			Log.Info("Failed to read enrichment cache store", "provider", provider, "name", name, "error", err)
+		}
+		if ok {
+			c.hits.Add(1)
+			c.setMemory(provider, name, person)
+
+			return person, true
+		}
+	}
+
+	c.misses.Add(1)
+
+	return entities.Person{}, false
+}
+
+func (c *NameCache) Set(ctx context.Context, provider, name string, person entities.Person) {
+	name = NormalizeName(name)
+
+	c.setMemory(provider, name, person)
+
+	if c.store != nil {
+		if err := c.store.Set(ctx, provider, name, person, c.ttl); err != nil {
+			Log.Info("Failed to write enrichment cache store", "provider", provider, "name", name, "error", err)
+		}
+	}
+}
+
+// Purge removes the cached results of every provider for name
+func (c *NameCache) Purge(ctx context.Context, name string) error {
+	name = NormalizeName(name)
+
+	c.mu.Lock()
+	for key, el := range c.items {
+		if el.Value.(*cacheEntry).name == name {
+			c.order.Remove(el)
+			delete(c.items, key)
+		}
+	}
+	c.mu.Unlock()
+
+	if c.store != nil {
+		return c.store.Purge(ctx, name)
+	}
+
+	return nil
+}
+
+func (c *NameCache) Stats() CacheStats {
+	c.mu.Lock()
+	entries := c.order.Len()
+	c.mu.Unlock()
+
+	return CacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: entries,
+	}
+}
+
+func (c *NameCache) getMemory(provider, name string) (entities.Person, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[cacheKey(provider, name)]
+	if !ok {
+		return entities.Person{}, false
+	}
+
+	entry := el.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(el)
+		delete(c.items, cacheKey(provider, name))
+
+		return entities.Person{}, false
+	}
+
+	c.order.MoveToFront(el)
+
+	return entry.person, true
+}
+
+func (c *NameCache) setMemory(provider, name string, person entities.Person) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey(provider, name)
+	entry := &cacheEntry{provider: provider, name: name, person: person, expiresAt: time.Now().Add(c.ttl)}
+
+	if el, ok := c.items[key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+
+		return
+	}
+
+	c.items[key] = c.order.PushFront(entry)
+
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		evicted := oldest.Value.(*cacheEntry)
+		delete(c.items, cacheKey(evicted.provider, evicted.name))
+	}
+}
+
+func cacheKey(provider, name string) string {
+	return provider + "\x00" + name
+}
+
+// CachedProvider serves a provider's results from the cache and only asks
+// the wrapped provider on a miss
+type CachedProvider struct {
+	interfaces.Provider
+	cache   *NameCache
+	refresh bool
+}
+
+func NewCachedProvider(provider interfaces.Provider, cache *NameCache) *CachedProvider {
+	return &CachedProvider{Provider: provider, cache: cache}
+}
+
+// Refreshing returns a provider over the same cache that always asks the
+// wrapped provider and stores its answer, for jobs replacing old results
+func (c *CachedProvider) Refreshing() *CachedProvider {
+	return &CachedProvider{Provider: c.Provider, cache: c.cache, refresh: true}
+}
+
+func (c *CachedProvider) get(ctx context.Context, person *entities.Person) (entities.Person, bool) {
+	if c.refresh {
+		return entities.Person{}, false
+	}
+
+	return c.cache.Get(ctx, c.key(person), person.Name)
+}
+
+// key separates results localized with a country hint. Nationality does
+// not depend on the hint.
+func (c *CachedProvider) key(person *entities.Person) string {
+	if person.CountryHint == "" || c.Field() == entities.FieldNationality {
+		return c.Name()
+	}
+
+	return c.Name() + "@" + person.CountryHint
+}
+
+func (c *CachedProvider) Enrich(ctx context.Context, person *entities.Person) error {
+	if cached, ok := c.get(ctx, person); ok {
+		Log.Debug("Enrichment cache hit", "provider", c.Name(), "name", person.Name)
+		mergeField(person, cached, c.Field())
+
+		return nil
+	}
+
+	if err := c.Provider.Enrich(ctx, person); err != nil {
+		return err
+	}
+
+	var result entities.Person
+	mergeField(&result, *person, c.Field())
+	c.cache.Set(ctx, c.key(person), person.Name, result)
+
+	return nil
+}
+
+// EnrichBatch serves cached people directly and enriches the misses in one batch
+func (c *CachedProvider) EnrichBatch(ctx context.Context, people []*entities.Person) []error {
+	errs := make([]error, len(people))
+
+	var misses []*entities.Person
+	var missIdx []int
+	for i, person := range people {
+		if cached, ok := c.get(ctx, person); ok {
+			mergeField(person, cached, c.Field())
+
+			continue
+		}
+
+		misses = append(misses, person)
+		missIdx = append(missIdx, i)
+	}
+
+	if len(misses) == 0 {
+		return errs
+	}
+
+	for i, err := range enrichEach(ctx, c.Provider, misses) {
+		errs[missIdx[i]] = err
+		if err != nil {
+			continue
+		}
+
+		var result entities.Person
+		mergeField(&result, *misses[i], c.Field())
+		c.cache.Set(ctx, c.key(misses[i]), misses[i].Name, result)
+	}
+
+	return errs
+}
@@ -0,0 +1,327 @@
+package extractors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/interfaces"
+	. "github.com/agl/fio/pkg/logger"
+)
+
+const DefaultProviderTimeout = 3 * time.Second
+
+// ChainOptions configures a Chain
+type ChainOptions struct {
+	// Timeout is the deadline of every provider call
+	Timeout time.Duration
+	// NationalityHint runs nationality providers first and passes their
+	// result as the country hint of the other providers when the person has
+	// no hint of its own. It trades latency for accuracy: such people wait
+	// for two rounds of providers instead of one.
+	NationalityHint bool
+}
+
+// Chain queries all providers concurrently, each under its own deadline,
+// and merges whatever they managed to return. One slow upstream does not
+// hold back the others, so the latency is bounded by the slowest provider.
+type Chain struct {
+	providers []interfaces.Provider
+	options   ChainOptions
+}
+
+func NewChain(options ChainOptions, providers ...interfaces.Provider) *Chain {
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultProviderTimeout
+	}
+
+	return &Chain{providers: providers, options: options}
+}
+
+// Enrich fills person with the results of every successful provider. When
+// some providers fail the partial result is kept and an *EnrichmentError
+// listing all failures is returned. If person.Pending is set only the
+// pending fields are enriched, locked fields are never touched.
+func (c *Chain) Enrich(ctx context.Context, person *entities.Person) error {
+	return c.EnrichBatch(ctx, []*entities.Person{person})[0]
+}
+
+type providerResult struct {
+	provider interfaces.Provider
+	indexes  []int
+	people   []*entities.Person
+	errs     []error
+}
+
+// EnrichBatch works like Enrich for many people. Every provider receives
+// the whole list at once so it can group names into batch requests; the
+// deadline of a provider grows with the number of batches. The returned
+// errors are aligned with people.
+func (c *Chain) EnrichBatch(ctx context.Context, people []*entities.Person) []error {
+	failures := make([][]ProviderFailure, len(people))
+
+	// only people without a hint of their own take the two round path,
+	// the others are enriched in a single concurrent round
+	var hinted, unhinted []int
+	for j, person := range people {
+		if c.options.NationalityHint && person.CountryHint == "" {
+			unhinted = append(unhinted, j)
+		} else {
+			hinted = append(hinted, j)
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		c.runSubset(ctx, c.providers, people, failures, hinted)
+	}()
+	go func() {
+		defer wg.Done()
+		c.runWithNationalityHint(ctx, people, failures, unhinted)
+	}()
+	wg.Wait()
+
+	// a field without a provider is never filled, report it like a failure
+	// so the policy rejects the person or leaves the field pending
+	for j, person := range people {
+		for _, field := range c.unserved(person) {
+			failures[j] = append(failures[j], ProviderFailure{Provider: "none", Field: field, Err: ErrNoProvider})
+		}
+	}
+
+	errs := make([]error, len(people))
+	for j := range people {
+		if len(failures[j]) > 0 {
+			errs[j] = &EnrichmentError{Failures: failures[j]}
+		}
+	}
+
+	return errs
+}
+
+// runWithNationalityHint enriches nationality first and passes it as the
+// country hint of the other providers
+func (c *Chain) runWithNationalityHint(ctx context.Context, people []*entities.Person, failures [][]ProviderFailure, indexes []int) {
+	if len(indexes) == 0 {
+		return
+	}
+
+	var first, rest []interfaces.Provider
+	for _, provider := range c.providers {
+		if provider.Field() == entities.FieldNationality {
+			first = append(first, provider)
+		} else {
+			rest = append(rest, provider)
+		}
+	}
+
+	c.runSubset(ctx, first, people, failures, indexes)
+
+	for _, j := range indexes {
+		people[j].CountryHint = people[j].Nationality
+	}
+
+	c.runSubset(ctx, rest, people, failures, indexes)
+}
+
+// runSubset runs providers for the people at indexes only
+func (c *Chain) runSubset(ctx context.Context, providers []interfaces.Provider, people []*entities.Person, failures [][]ProviderFailure, indexes []int) {
+	if len(indexes) == 0 {
+		return
+	}
+
+	subset := make([]*entities.Person, len(indexes))
+	subsetFailures := make([][]ProviderFailure, len(indexes))
+	for k, j := range indexes {
+		subset[k] = people[j]
+	}
+
+	c.run(ctx, providers, subset, subsetFailures)
+
+	for k, j := range indexes {
+		failures[j] = append(failures[j], subsetFailures[k]...)
+	}
+}
+
+// unserved returns the fields person needs that no provider enriches
+func (c *Chain) unserved(person *entities.Person) []string {
+	var fields []string
+	for _, field := range entities.EnrichedFields {
+		if len(person.Pending) > 0 && !slices.Contains(person.Pending, field) {
+			continue
+		}
+		if slices.Contains(person.Locked, field) {
+			continue
+		}
+
+		served := slices.ContainsFunc(c.providers, func(p interfaces.Provider) bool {
+			return p.Field() == field
+		})
+		if !served {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// run queries providers concurrently, merges their results into people and
+// appends the failures of every person
+func (c *Chain) run(ctx context.Context, providers []interfaces.Provider, people []*entities.Person, failures [][]ProviderFailure) {
+	results := make([]providerResult, len(providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		var indexes []int
+		var locals []*entities.Person
+		for j, person := range people {
+			if len(person.Pending) > 0 && !slices.Contains(person.Pending, provider.Field()) {
+				continue
+			}
+			if slices.Contains(person.Locked, provider.Field()) {
+				continue
+			}
+
+			local := *person
+			local.Enrichment = nil
+			indexes = append(indexes, j)
+			locals = append(locals, &local)
+		}
+
+		if len(locals) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			batches := (len(locals) + MaxBatchSize - 1) / MaxBatchSize
+			providerCtx, cancel := context.WithTimeout(ctx, c.options.Timeout*time.Duration(batches))
+			defer cancel()
+
+			var errs []error
+			if len(locals) == 1 {
+				errs = []error{provider.Enrich(providerCtx, locals[0])}
+			} else {
+				errs = enrichEach(providerCtx, provider, locals)
+			}
+
+			results[i] = providerResult{provider: provider, indexes: indexes, people: locals, errs: errs}
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if result.provider == nil {
+			continue
+		}
+
+		for k, j := range result.indexes {
+			if err := result.errs[k]; err != nil {
+				Log.Info("Failed to get "+result.provider.Field(), "provider", result.provider.Name(), "name", people[j].Name, "error", err)
+
+				failures[j] = append(failures[j], ProviderFailure{
+					Provider: result.provider.Name(),
+					Field:    result.provider.Field(),
+					Err:      err,
+				})
+
+				continue
+			}
+
+			mergeField(people[j], *result.people[k], result.provider.Field())
+		}
+	}
+}
+
+// mergeField copies a single enriched field and its provenance from src into dst
+func mergeField(dst *entities.Person, src entities.Person, field string) {
+	switch field {
+	case entities.FieldAge:
+		dst.Age = src.Age
+	case entities.FieldGender:
+		dst.Gender = src.Gender
+	case entities.FieldNationality:
+		dst.Nationality = src.Nationality
+	}
+
+	if provenance, ok := src.Enrichment[field]; ok {
+		if dst.Enrichment == nil {
+			dst.Enrichment = make(map[string]entities.Provenance)
+		}
+
+		dst.Enrichment[field] = provenance
+	}
+}
+
+// ProviderFailure describes one provider that could not enrich a person
+type ProviderFailure struct {
+	Provider string
+	Field    string
+	Err      error
+}
+
+// EnrichmentError is the combined report of all failed providers
+type EnrichmentError struct {
+	Failures []ProviderFailure
+}
+
+func (e *EnrichmentError) Error() string {
+	msgs := make([]string, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		msgs = append(msgs, fmt.Sprintf("failed to get %s from %s: %v", f.Field, f.Provider, f.Err))
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+func (e *EnrichmentError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		errs = append(errs, f.Err)
+	}
+
+	return errs
+}
+
+// UnknownFields returns the fields every provider answered for without
+// knowing the name, as opposed to failing to answer
+func (e *EnrichmentError) UnknownFields() []string {
+	var fields []string
+	for _, f := range e.Failures {
+		if !slices.Contains(fields, f.Field) && e.unknown(f.Field) {
+			fields = append(fields, f.Field)
+		}
+	}
+
+	return fields
+}
+
+func (e *EnrichmentError) unknown(field string) bool {
+	for _, f := range e.Failures {
+		if f.Field == field && !errors.Is(f.Err, ErrNoData) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Fields returns the fields that could not be enriched
+func (e *EnrichmentError) Fields() []string {
+	fields := make([]string, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		fields = append(fields, f.Field)
+	}
+
+	return fields
+}
@@ -0,0 +1,45 @@
+package extractors
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoData is returned when a provider answered but has no prediction for the name
+var ErrNoData = errors.New("no data found")
+
+// ErrNoProvider is reported for a field no configured provider can enrich
+var ErrNoProvider = errors.New("no provider configured")
+
+// ErrUpstreamRateLimited is matched by every *RateLimitError
+var ErrUpstreamRateLimited = errors.New("upstream rate limited")
+
+// RateLimitError is returned when a provider has exhausted its request quota
+type RateLimitError struct {
+	Provider   string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s: %v, retry after %s", e.Provider, ErrUpstreamRateLimited, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrUpstreamRateLimited
+}
+
+// StatusError is returned when a provider answers with a non 2xx status
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s: unexpected status %d", e.Provider, e.StatusCode)
+	}
+
+	return fmt.Sprintf("%s: unexpected status %d: %s", e.Provider, e.StatusCode, e.Message)
+}
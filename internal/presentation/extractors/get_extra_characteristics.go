@@ -2,170 +2,92 @@ package extractors
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"time"
+	"errors"
+	"slices"
+	"strings"
 
 	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/interfaces"
 	. "github.com/agl/fio/pkg/logger"
 )
 
-const timeout = 3 * time.Second
+// Policy decides what happens to a new person when enrichment fails
+type Policy string
 
-func GetExtraUserInfoByName(name string, received_person entities.ReceivedPerson) (entities.Person, error) {
-	person := entities.Person{
-		Name: received_person.Name,
-		Surname: received_person.Surname,
-		Patronymic: received_person.Patronymic,
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	err := getAge(ctx, name, &person)
-	if err != nil {
-		Log.Info("Failed to get age", "name", name, "error", err)
-
-		return entities.Person{}, fmt.Errorf("failed to get age: %w", err)
-	}
-
-	err = getGender(ctx, name, &person)
-	if err != nil {
-		Log.Info("Failed to get gender", "name", name, "error", err)
-
-		return entities.Person{}, fmt.Errorf("failed to get gender: %w", err)
-	}
-
-	err = getNationality(ctx, name, &person)
-	if err != nil {
-		Log.Info("Failed to get nationality", "name", name, "error", err)
+const (
+	// PolicyStrict rejects the person if any field could not be enriched
+	PolicyStrict Policy = "strict"
+	// PolicyBestEffort keeps what could be enriched and marks the rest as pending
+	PolicyBestEffort Policy = "best-effort"
+)
 
-		return entities.Person{}, fmt.Errorf("failed to get nationality: %w", err)
+func ParsePolicy(s string) Policy {
+	if Policy(s) == PolicyBestEffort {
+		return PolicyBestEffort
 	}
 
-	return person, nil
+	return PolicyStrict
 }
 
-func getAge(ctx context.Context, name string, person *entities.Person) error {
-	agifu_url := os.Getenv("AGIFY_URL")
-
-	url := agifu_url + "=" + name
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		Log.Info("Failed to create age request", "name", name, "error", err)
-
-		return err
-	}
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		Log.Info("Failed to get age from API", "name", name, "error", err)
-
-		return err
-	}
-	defer resp.Body.Close()
+var enrichedFields = []string{entities.FieldAge, entities.FieldGender, entities.FieldNationality}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		Log.Info("Failed to read response body for age", "name", name, "error", err)
+// GetExtraUserInfoByName enriches the received person. Under the strict
+// policy a failure is returned together with the partially enriched person;
+// under best-effort the failed fields are listed in person.Pending instead.
+func GetExtraUserInfoByName(ctx context.Context, enricher interfaces.Enricher, policy Policy, received_person entities.ReceivedPerson) (entities.Person, error) {
+	person := newPerson(received_person)
 
-		return err
-	}
+	err := enricher.Enrich(ctx, &person)
 
-	if err := json.Unmarshal(body, person); err != nil {
-		Log.Info("Failed to unmarshal age response", "name", name, "error", err)
-
-		return err
-	}
-
-	Log.Info("Successfully retrieved person age", "age", person.Age)
-
-	return nil
+	return person, applyPolicy(&person, policy, err)
 }
 
-func getGender(ctx context.Context, name string, person *entities.Person) error {
-	genderize_url := os.Getenv("GENDERIZE_URL")
-
-	url := genderize_url + "=" + name
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		Log.Info("Failed to create gender request", "name", name, "error", err)
-
-		return err
+// GetExtraUserInfoByNames enriches many received people, grouping names into
+// batch requests where the enricher supports it. The returned errors are
+// aligned with the people and follow the same policy as GetExtraUserInfoByName.
+func GetExtraUserInfoByNames(ctx context.Context, enricher interfaces.Enricher, policy Policy, received []entities.ReceivedPerson) ([]entities.Person, []error) {
+	people := make([]entities.Person, len(received))
+	ptrs := make([]*entities.Person, len(received))
+	for i, r := range received {
+		people[i] = newPerson(r)
+		ptrs[i] = &people[i]
 	}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		Log.Info("Failed to get gender from API", "name", name, "error", err)
-
-		return err
+	errs := enrichEach(ctx, enricher, ptrs)
+	for i := range people {
+		errs[i] = applyPolicy(&people[i], policy, errs[i])
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		Log.Info("Failed to read response body for gender", "name", name, "error", err)
 
-		return err
-	}
-
-	if err := json.Unmarshal(body, person); err != nil {
-		Log.Info("Failed to unmarshal gender response", "name", name, "error", err)
-
-		return err
-	}
-
-	Log.Info("Successfully retrieved person gender", "gender", person.Gender)
-
-	return nil
+	return people, errs
 }
 
-func getNationality(ctx context.Context, name string, person *entities.Person) error {
-	nationalize_url := os.Getenv("NATIONALIZE_URL")
-
-	url := nationalize_url + "=" + name
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		Log.Info("Failed to create nationality request", "name", name, "error", err)
-
-		return err
-	}
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		Log.Info("Failed to get nationality from API", "name", name, "error", err)
-
-		return err
+func newPerson(received_person entities.ReceivedPerson) entities.Person {
+	person := entities.Person{
+		Name:       received_person.Name,
+		Surname:    received_person.Surname,
+		Patronymic: received_person.Patronymic,
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		Log.Info("Failed to read response body for nationality", "name", name, "error", err)
-
-		return err
+	if received_person.CountryID != nil {
+		person.CountryHint = strings.ToUpper(strings.TrimSpace(*received_person.CountryID))
 	}
 
-	var result entities.Nationalities
-	if err := json.Unmarshal(body, &result); err != nil {
-		Log.Info("Failed to unmarshal nationality response", "name", name, "error", err)
+	return person
+}
 
+func applyPolicy(person *entities.Person, policy Policy, err error) error {
+	if err == nil || policy == PolicyStrict {
 		return err
 	}
 
-	if len(result.Countries) > 0 {
-		person.Nationality = result.Countries[0].CountryID
-
-		return nil
+	var enrichmentErr *EnrichmentError
+	if errors.As(err, &enrichmentErr) {
+		person.Pending = enrichmentErr.Fields()
+	} else {
+		person.Pending = slices.Clone(enrichedFields)
 	}
 
-	Log.Info("No nationality found", "name", name)
+	Log.Info("Person saved with pending enrichment", "name", person.Name, "pending", person.Pending, "error", err)
 
-	return fmt.Errorf("no nationality found")
+	return nil
 }
@@ -0,0 +1,241 @@
+package extractors
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/interfaces"
+	. "github.com/agl/fio/pkg/logger"
+)
+
+//go:embed data/names.csv
+var bundledData embed.FS
+
+const bundledDataset = "data/names.csv"
+
+// NameStats are the statistics of one first name in an offline dataset
+type NameStats struct {
+	Name               string  `json:"name"`
+	Count              int     `json:"count"`
+	Age                int     `json:"age"`
+	Gender             string  `json:"gender"`
+	GenderProbability  float64 `json:"gender_probability"`
+	CountryID          string  `json:"country_id"`
+	CountryProbability float64 `json:"country_probability"`
+}
+
+// OfflineDataset answers enrichment lookups from local name statistics,
+// for environments that cannot reach the public APIs
+type OfflineDataset struct {
+	names map[string]NameStats
+}
+
+// LoadOfflineDataset reads a CSV or JSON dataset from path. An empty path
+// loads the dataset bundled with the binary.
+//
+// CSV files need a header with the NameStats JSON keys; JSON files hold an
+// array of NameStats objects.
+func LoadOfflineDataset(path string) (*OfflineDataset, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	if path == "" {
+		path = bundledDataset
+		data, err = bundledData.ReadFile(bundledDataset)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset %s: %w", path, err)
+	}
+
+	var stats []NameStats
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &stats)
+	} else {
+		stats, err = parseNameStatsCSV(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dataset %s: %w", path, err)
+	}
+
+	dataset := &OfflineDataset{names: make(map[string]NameStats, len(stats))}
+	for _, s := range stats {
+		dataset.names[NormalizeName(s.Name)] = s
+	}
+
+	Log.Info("Offline enrichment dataset loaded", "path", path, "names", len(dataset.names))
+
+	return dataset, nil
+}
+
+func parseNameStatsCSV(data []byte) ([]NameStats, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.TrimSpace(column)] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("missing name column")
+	}
+
+	get := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+
+		return ""
+	}
+
+	var stats []NameStats
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		s := NameStats{
+			Name:      get(record, "name"),
+			Gender:    get(record, "gender"),
+			CountryID: get(record, "country_id"),
+		}
+		s.Count, _ = strconv.Atoi(get(record, "count"))
+		s.Age, _ = strconv.Atoi(get(record, "age"))
+		s.GenderProbability, _ = strconv.ParseFloat(get(record, "gender_probability"), 64)
+		s.CountryProbability, _ = strconv.ParseFloat(get(record, "country_probability"), 64)
+
+		stats = append(stats, s)
+	}
+
+	return stats, nil
+}
+
+// Providers returns one provider per enriched field backed by the dataset
+func (d *OfflineDataset) Providers() []interfaces.Provider {
+	return []interfaces.Provider{
+		d.Provider(entities.FieldAge),
+		d.Provider(entities.FieldGender),
+		d.Provider(entities.FieldNationality),
+	}
+}
+
+func (d *OfflineDataset) Provider(field string) *OfflineProvider {
+	return &OfflineProvider{dataset: d, field: field}
+}
+
+// OfflineProvider fills a single field from an OfflineDataset
+type OfflineProvider struct {
+	dataset *OfflineDataset
+	field   string
+}
+
+func (o *OfflineProvider) Name() string  { return "offline" }
+func (o *OfflineProvider) Field() string { return o.field }
+
+func (o *OfflineProvider) Enrich(ctx context.Context, person *entities.Person) error {
+	s, ok := o.dataset.names[NormalizeName(person.Name)]
+	if !ok {
+		return fmt.Errorf("%s not in offline dataset: %w", person.Name, ErrNoData)
+	}
+
+	count := s.Count
+	provenance := entities.Provenance{Provider: o.Name(), Count: &count}
+
+	switch o.field {
+	case entities.FieldAge:
+		if s.Age <= 0 {
+			return fmt.Errorf("no age for %s in offline dataset: %w", person.Name, ErrNoData)
+		}
+
+		person.Age = s.Age
+	case entities.FieldGender:
+		if s.Gender == "" {
+			return fmt.Errorf("no gender for %s in offline dataset: %w", person.Name, ErrNoData)
+		}
+
+		probability := s.GenderProbability
+		person.Gender = s.Gender
+		provenance.Probability = &probability
+		provenance.Candidates = []entities.Candidate{{Value: s.Gender, Probability: probability}}
+	case entities.FieldNationality:
+		if s.CountryID == "" {
+			return fmt.Errorf("no nationality for %s in offline dataset: %w", person.Name, ErrNoData)
+		}
+
+		probability := s.CountryProbability
+		person.Nationality = s.CountryID
+		provenance.Probability = &probability
+		provenance.Candidates = []entities.Candidate{{Value: s.CountryID, Probability: probability}}
+	}
+
+	setProvenance(person, o.field, provenance)
+
+	return nil
+}
+
+// FallbackProvider asks the secondary provider for people the primary one
+// could not enrich, e.g. the offline dataset behind an HTTP API
+type FallbackProvider struct {
+	interfaces.Provider
+	secondary interfaces.Provider
+}
+
+func NewFallbackProvider(primary, secondary interfaces.Provider) *FallbackProvider {
+	return &FallbackProvider{Provider: primary, secondary: secondary}
+}
+
+func (f *FallbackProvider) Enrich(ctx context.Context, person *entities.Person) error {
+	err := f.Provider.Enrich(ctx, person)
+	if err == nil {
+		return nil
+	}
+
+	Log.Info("Falling back to secondary provider", "provider", f.Name(), "fallback", f.secondary.Name(), "name", person.Name, "error", err)
+
+	if fallbackErr := f.secondary.Enrich(ctx, person); fallbackErr != nil {
+		return fmt.Errorf("%w; fallback %s: %w", err, f.secondary.Name(), fallbackErr)
+	}
+
+	return nil
+}
+
+func (f *FallbackProvider) EnrichBatch(ctx context.Context, people []*entities.Person) []error {
+	errs := enrichEach(ctx, f.Provider, people)
+
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		if fallbackErr := f.secondary.Enrich(ctx, people[i]); fallbackErr != nil {
+			errs[i] = fmt.Errorf("%w; fallback %s: %w", err, f.secondary.Name(), fallbackErr)
+
+			continue
+		}
+
+		errs[i] = nil
+	}
+
+	return errs
+}
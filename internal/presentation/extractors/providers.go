@@ -0,0 +1,196 @@
+package extractors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/interfaces"
+	. "github.com/agl/fio/pkg/logger"
+)
+
+// ProvidersFromEnv builds the public API providers from AGIFY_URL,
+// GENDERIZE_URL and NATIONALIZE_URL. A provider whose URL is empty is
+// disabled and left out.
+func ProvidersFromEnv(client *http.Client, retry RetryPolicy) []interfaces.Provider {
+	var providers []interfaces.Provider
+
+	if u := os.Getenv("AGIFY_URL"); u != "" {
+		providers = append(providers, NewAgeProvider(u, client, retry))
+	}
+	if u := os.Getenv("GENDERIZE_URL"); u != "" {
+		providers = append(providers, NewGenderProvider(u, client, retry))
+	}
+	if u := os.Getenv("NATIONALIZE_URL"); u != "" {
+		providers = append(providers, NewNationalityProvider(u, client, retry))
+	}
+
+	return providers
+}
+
+// AgeProvider predicts age with an agify compatible API
+type AgeProvider struct {
+	api *upstream
+}
+
+func NewAgeProvider(url string, client *http.Client, retry RetryPolicy) *AgeProvider {
+	return &AgeProvider{api: newUpstream("agify", url, client, retry)}
+}
+
+func (a *AgeProvider) Name() string  { return a.api.provider }
+func (a *AgeProvider) Field() string { return entities.FieldAge }
+
+type ageResult struct {
+	Count int  `json:"count"`
+	Age   *int `json:"age"`
+}
+
+func (a *AgeProvider) Enrich(ctx context.Context, person *entities.Person) error {
+	var result ageResult
+
+	if err := a.api.get(ctx, person.Name, person.CountryHint, &result); err != nil {
+		return err
+	}
+
+	return a.apply(person, result)
+}
+
+func (a *AgeProvider) EnrichBatch(ctx context.Context, people []*entities.Person) []error {
+	return enrichBatch(ctx, a.api, people, true, a.apply)
+}
+
+func (a *AgeProvider) apply(person *entities.Person, result ageResult) error {
+	if result.Age == nil {
+		Log.Info("No age found", "name", person.Name)
+
+		return fmt.Errorf("no age found: %w", ErrNoData)
+	}
+
+	person.Age = *result.Age
+	setProvenance(person, entities.FieldAge, entities.Provenance{
+		Provider:    a.Name(),
+		Count:       &result.Count,
+		CountryHint: person.CountryHint,
+	})
+
+	Log.Info("Successfully retrieved person age", "age", person.Age)
+
+	return nil
+}
+
+// GenderProvider predicts gender with a genderize compatible API
+type GenderProvider struct {
+	api *upstream
+}
+
+func NewGenderProvider(url string, client *http.Client, retry RetryPolicy) *GenderProvider {
+	return &GenderProvider{api: newUpstream("genderize", url, client, retry)}
+}
+
+func (g *GenderProvider) Name() string  { return g.api.provider }
+func (g *GenderProvider) Field() string { return entities.FieldGender }
+
+type genderResult struct {
+	Count       int     `json:"count"`
+	Gender      *string `json:"gender"`
+	Probability float64 `json:"probability"`
+}
+
+func (g *GenderProvider) Enrich(ctx context.Context, person *entities.Person) error {
+	var result genderResult
+
+	if err := g.api.get(ctx, person.Name, person.CountryHint, &result); err != nil {
+		return err
+	}
+
+	return g.apply(person, result)
+}
+
+func (g *GenderProvider) EnrichBatch(ctx context.Context, people []*entities.Person) []error {
+	return enrichBatch(ctx, g.api, people, true, g.apply)
+}
+
+func (g *GenderProvider) apply(person *entities.Person, result genderResult) error {
+	if result.Gender == nil {
+		Log.Info("No gender found", "name", person.Name)
+
+		return fmt.Errorf("no gender found: %w", ErrNoData)
+	}
+
+	person.Gender = *result.Gender
+	setProvenance(person, entities.FieldGender, entities.Provenance{
+		Provider:    g.Name(),
+		Probability: &result.Probability,
+		Count:       &result.Count,
+		Candidates:  []entities.Candidate{{Value: *result.Gender, Probability: result.Probability}},
+		CountryHint: person.CountryHint,
+	})
+
+	Log.Info("Successfully retrieved person gender", "gender", person.Gender)
+
+	return nil
+}
+
+// NationalityProvider predicts nationality with a nationalize compatible API
+type NationalityProvider struct {
+	api *upstream
+}
+
+func NewNationalityProvider(url string, client *http.Client, retry RetryPolicy) *NationalityProvider {
+	return &NationalityProvider{api: newUpstream("nationalize", url, client, retry)}
+}
+
+func (n *NationalityProvider) Name() string  { return n.api.provider }
+func (n *NationalityProvider) Field() string { return entities.FieldNationality }
+
+func (n *NationalityProvider) Enrich(ctx context.Context, person *entities.Person) error {
+	var result entities.Nationalities
+
+	if err := n.api.get(ctx, person.Name, "", &result); err != nil {
+		return err
+	}
+
+	return n.apply(person, result)
+}
+
+func (n *NationalityProvider) EnrichBatch(ctx context.Context, people []*entities.Person) []error {
+	return enrichBatch(ctx, n.api, people, false, n.apply)
+}
+
+func (n *NationalityProvider) apply(person *entities.Person, result entities.Nationalities) error {
+	if len(result.Countries) == 0 {
+		Log.Info("No nationality found", "name", person.Name)
+
+		return fmt.Errorf("no nationality found: %w", ErrNoData)
+	}
+
+	candidates := make([]entities.Candidate, 0, len(result.Countries))
+	for _, country := range result.Countries {
+		candidates = append(candidates, entities.Candidate{Value: country.CountryID, Probability: country.Probability})
+	}
+
+	person.Nationality = result.Countries[0].CountryID
+	setProvenance(person, entities.FieldNationality, entities.Provenance{
+		Provider:    n.Name(),
+		Probability: &result.Countries[0].Probability,
+		Count:       &result.Count,
+		Candidates:  candidates,
+	})
+
+	Log.Info("Successfully retrieved person nationality", "nationality", person.Nationality)
+
+	return nil
+}
+
+// setProvenance records where field of person came from, stamped with the current time
+func setProvenance(person *entities.Person, field string, provenance entities.Provenance) {
+	if person.Enrichment == nil {
+		person.Enrichment = make(map[string]entities.Provenance)
+	}
+
+	provenance.EnrichedAt = time.Now().UTC()
+	person.Enrichment[field] = provenance
+}
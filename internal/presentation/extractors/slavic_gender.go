@@ -0,0 +1,148 @@
+package extractors
+
+import (
+	"context"
+	"strings"
+
+	"github.com/agl/fio/internal/domain/entities"
+	"github.com/agl/fio/internal/domain/interfaces"
+	. "github.com/agl/fio/pkg/logger"
+)
+
+const (
+	genderMale   = "male"
+	genderFemale = "female"
+)
+
+type genderRule struct {
+	suffix string
+	gender string
+}
+
+// Patronymics determine gender almost perfectly. Ukrainian forms and the
+// Turkic "oglu/kyzy" particles are included.
+var patronymicRules = []genderRule{
+	{"ович", genderMale}, {"евич", genderMale}, {"ёвич", genderMale}, {"ич", genderMale}, {"оглы", genderMale},
+	{"овна", genderFemale}, {"евна", genderFemale}, {"ична", genderFemale}, {"инична", genderFemale},
+	{"івна", genderFemale}, {"ївна", genderFemale}, {"кызы", genderFemale},
+
+	{"ovich", genderMale}, {"evich", genderMale}, {"ovych", genderMale}, {"evych", genderMale},
+	{"ich", genderMale}, {"ych", genderMale}, {"oglu", genderMale}, {"ogly", genderMale},
+	{"ovna", genderFemale}, {"evna", genderFemale}, {"ichna", genderFemale}, {"ivna", genderFemale},
+	{"yivna", genderFemale}, {"inichna", genderFemale}, {"kyzy", genderFemale}, {"kizi", genderFemale},
+}
+
+// Surname endings are weaker evidence. Endings shared by both genders
+// (-ko, -uk, -enko, -ykh, -yan) are deliberately absent, and the Latin
+// "-in" is left out because it is common outside Slavic names.
+var surnameRules = []genderRule{
+	{"ов", genderMale}, {"ев", genderMale}, {"ёв", genderMale}, {"ин", genderMale}, {"ын", genderMale},
+	{"ский", genderMale}, {"цкий", genderMale}, {"ской", genderMale}, {"цкой", genderMale},
+	{"ова", genderFemale}, {"ева", genderFemale}, {"ёва", genderFemale}, {"ина", genderFemale}, {"ына", genderFemale},
+	{"ская", genderFemale}, {"цкая", genderFemale},
+
+	{"ov", genderMale}, {"ev", genderMale}, {"yov", genderMale},
+	{"sky", genderMale}, {"skiy", genderMale}, {"skii", genderMale}, {"skij", genderMale}, {"skyi", genderMale},
+	{"ova", genderFemale}, {"eva", genderFemale}, {"yova", genderFemale},
+	{"skaya", genderFemale}, {"skaia", genderFemale}, {"skaja", genderFemale},
+	{"ski", genderMale}, {"cki", genderMale}, {"ska", genderFemale}, {"cka", genderFemale},
+}
+
+const (
+	patronymicRuleProbability = 0.99
+	surnameRuleProbability    = 0.95
+	minRuleWordLength         = 4
+)
+
+// InferSlavicGender infers gender from Slavic patronymic and surname
+// morphology, in Cyrillic or Latin transliteration. The patronymic wins over
+// the surname. rule names the ending that decided, e.g. "patronymic:-ovna";
+// ok is false when neither is conclusive.
+func InferSlavicGender(surname string, patronymic *string) (gender, rule string, probability float64, ok bool) {
+	if patronymic != nil {
+		if rule, ok := matchGenderRule(*patronymic, patronymicRules); ok {
+			return rule.gender, "patronymic:-" + rule.suffix, patronymicRuleProbability, true
+		}
+	}
+
+	if rule, ok := matchGenderRule(surname, surnameRules); ok {
+		return rule.gender, "surname:-" + rule.suffix, surnameRuleProbability, true
+	}
+
+	return "", "", 0, false
+}
+
+// matchGenderRule returns the rule with the longest suffix matching word
+func matchGenderRule(word string, rules []genderRule) (genderRule, bool) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if len([]rune(word)) < minRuleWordLength {
+		return genderRule{}, false
+	}
+
+	var best genderRule
+	for _, rule := range rules {
+		if strings.HasSuffix(word, rule.suffix) && len(rule.suffix) > len(best.suffix) {
+			best = rule
+		}
+	}
+
+	return best, best.suffix != ""
+}
+
+// SlavicGenderProvider infers gender from the patronymic and surname before
+// asking the wrapped provider, which is only called for ambiguous names
+type SlavicGenderProvider struct {
+	interfaces.Provider
+}
+
+func NewSlavicGenderProvider(fallback interfaces.Provider) *SlavicGenderProvider {
+	return &SlavicGenderProvider{Provider: fallback}
+}
+
+func (s *SlavicGenderProvider) Enrich(ctx context.Context, person *entities.Person) error {
+	if s.infer(person) {
+		return nil
+	}
+
+	return s.Provider.Enrich(ctx, person)
+}
+
+func (s *SlavicGenderProvider) EnrichBatch(ctx context.Context, people []*entities.Person) []error {
+	errs := make([]error, len(people))
+
+	var ambiguous []*entities.Person
+	var indexes []int
+	for i, person := range people {
+		if !s.infer(person) {
+			ambiguous = append(ambiguous, person)
+			indexes = append(indexes, i)
+		}
+	}
+
+	if len(ambiguous) > 0 {
+		for i, err := range enrichEach(ctx, s.Provider, ambiguous) {
+			errs[indexes[i]] = err
+		}
+	}
+
+	return errs
+}
+
+func (s *SlavicGenderProvider) infer(person *entities.Person) bool {
+	gender, rule, probability, ok := InferSlavicGender(person.Surname, person.Patronymic)
+	if !ok {
+		return false
+	}
+
+	person.Gender = gender
+	setProvenance(person, entities.FieldGender, entities.Provenance{
+		Provider:    "slavic-rules",
+		Probability: &probability,
+		Candidates:  []entities.Candidate{{Value: gender, Probability: probability}},
+		Rule:        rule,
+	})
+
+	Log.Info("Gender inferred from name morphology", "surname", person.Surname, "gender", gender, "rule", rule)
+
+	return true
+}
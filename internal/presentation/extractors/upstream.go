@@ -0,0 +1,229 @@
+package extractors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/agl/fio/pkg/logger"
+)
+
+// RetryPolicy controls how failed upstream calls are retried
+type RetryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:  3,
+	BaseDelay: 200 * time.Millisecond,
+	MaxDelay:  2 * time.Second,
+}
+
+// backoff returns the jittered delay before the given retry (starting at 1)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay << (retry - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	half := int64(delay / 2)
+
+	return time.Duration(half + rand.Int64N(half+1))
+}
+
+// upstream is an HTTP enrichment API with retries and rate limit tracking.
+// The APIs report their quota with X-Rate-Limit-Remaining and
+// X-Rate-Limit-Reset (seconds until the quota is renewed); once it is
+// exhausted calls fail fast until the reset.
+type upstream struct {
+	provider string
+	endpoint string
+	client   *http.Client
+	retry    RetryPolicy
+
+	mu           sync.Mutex
+	blockedUntil time.Time
+}
+
+func newUpstream(provider, endpoint string, client *http.Client, retry RetryPolicy) *upstream {
+	if retry.Attempts <= 0 {
+		retry.Attempts = 1
+	}
+
+	return &upstream{provider: provider, endpoint: endpoint, client: client, retry: retry}
+}
+
+// get queries the API for name and decodes the JSON response into out.
+// endpoint is expected to end with the name parameter key, e.g. "https://api.agify.io/?name".
+// A non empty country is sent as the country_id localization parameter.
+func (u *upstream) get(ctx context.Context, name, country string, out any) error {
+	return u.request(ctx, u.endpoint+"="+url.QueryEscape(name)+countryParam(country), []string{name}, out)
+}
+
+// getBatch queries the API for several names at once with repeated name[]
+// parameters; the response is a JSON array in the order of names.
+func (u *upstream) getBatch(ctx context.Context, names []string, country string, out any) error {
+	cut := strings.LastIndexAny(u.endpoint, "?&") + 1
+	prefix, key := u.endpoint[:cut], u.endpoint[cut:]
+
+	params := make([]string, 0, len(names))
+	for _, name := range names {
+		params = append(params, key+"[]="+url.QueryEscape(name))
+	}
+
+	return u.request(ctx, prefix+strings.Join(params, "&")+countryParam(country), names, out)
+}
+
+func countryParam(country string) string {
+	if country == "" {
+		return ""
+	}
+
+	return "&country_id=" + url.QueryEscape(country)
+}
+
+// request performs a GET with retries and decodes the JSON response into out
+func (u *upstream) request(ctx context.Context, target string, names []string, out any) error {
+	var err error
+	for attempt := 1; attempt <= u.retry.Attempts; attempt++ {
+		if attempt > 1 {
+			delay := u.retry.backoff(attempt - 1)
+
+			Log.Debug("Retrying upstream request", "provider", u.provider, "names", names, "attempt", attempt, "delay", delay)
+
+			select {
+			case <-ctx.Done():
+				return errors.Join(err, ctx.Err())
+			case <-time.After(delay):
+			}
+		}
+
+		attemptCtx, cancel := attemptContext(ctx, u.retry.Attempts-attempt+1)
+		var retryable bool
+		retryable, err = u.do(attemptCtx, target, out)
+		timedOut := errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+		cancel()
+
+		if err == nil || !(retryable || timedOut) {
+			return err
+		}
+
+		Log.Info("Upstream request failed", "provider", u.provider, "names", names, "attempt", attempt, "error", err)
+	}
+
+	return err
+}
+
+// attemptContext gives an attempt its share of the time left to ctx, so a
+// slow response is cut short while there is still time to retry it
+func attemptContext(ctx context.Context, attemptsLeft int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || attemptsLeft <= 1 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, time.Until(deadline)/time.Duration(attemptsLeft))
+}
+
+// do performs a single request and reports whether a failure is worth retrying
+func (u *upstream) do(ctx context.Context, target string, out any) (bool, error) {
+	if wait := u.rateLimitWait(); wait > 0 {
+		return false, &RateLimitError{Provider: u.provider, RetryAfter: wait}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		Log.Info("Failed to create request", "provider", u.provider, "error", err)
+
+		return false, err
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, err
+		}
+
+		var netErr net.Error
+		retryable := errors.As(err, &netErr) && netErr.Timeout()
+
+		return retryable, err
+	}
+	defer resp.Body.Close()
+
+	u.trackRateLimit(resp)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		Log.Info("Failed to read response body", "provider", u.provider, "error", err)
+
+		return true, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return false, &RateLimitError{Provider: u.provider, RetryAfter: u.rateLimitWait()}
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return true, u.statusError(resp.StatusCode, body)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return false, u.statusError(resp.StatusCode, body)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		Log.Info("Failed to unmarshal response", "provider", u.provider, "error", err)
+
+		return false, err
+	}
+
+	return false, nil
+}
+
+func (u *upstream) statusError(status int, body []byte) *StatusError {
+	var payload struct {
+		Error string `json:"error"`
+	}
+	_ = json.Unmarshal(body, &payload)
+
+	return &StatusError{Provider: u.provider, StatusCode: status, Message: payload.Error}
+}
+
+// trackRateLimit blocks further calls until the reset when the quota is used up
+func (u *upstream) trackRateLimit(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-Rate-Limit-Remaining"))
+	exhausted := (err == nil && remaining <= 0) || resp.StatusCode == http.StatusTooManyRequests
+	if !exhausted {
+		return
+	}
+
+	reset, err := strconv.Atoi(resp.Header.Get("X-Rate-Limit-Reset"))
+	if err != nil {
+		reset, err = strconv.Atoi(resp.Header.Get("Retry-After"))
+	}
+	if err != nil || reset <= 0 {
+		reset = 60
+	}
+
+	u.mu.Lock()
+	u.blockedUntil = time.Now().Add(time.Duration(reset) * time.Second)
+	u.mu.Unlock()
+
+	Log.Warn("Upstream rate limit exhausted", "provider", u.provider, "reset_in", reset)
+}
+
+func (u *upstream) rateLimitWait() time.Duration {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return time.Until(u.blockedUntil)
+}